@@ -0,0 +1,156 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// LogCommon is an access log format that writes records in the Apache Common
+	// Log Format.
+	LogCommon format = iota
+	// LogCombined is an access log format that writes records in the Apache
+	// Combined Log Format, which is the Common Log Format with the Referer and
+	// User-Agent Headers.
+	LogCombined
+	// LogFmt is an access log format that writes records as 'key=value' pairs.
+	LogFmt
+	// LogJSON is an access log format that writes records as JSON objects, one
+	// per line.
+	LogJSON
+)
+
+type format uint8
+
+// AccessLog is a struct that can be set as the 'Access' field of a Mux to write
+// a record of every request handled to the Writer in the specified Format. Writes
+// to the Writer are serialized.
+//
+// Sample can be set to a value between zero and one to only write the supplied
+// fraction of records. Zero (or any value of one and above) will write every
+// record.
+//
+// Exclude is a list of path prefixes that will not be written, which can be used
+// to ignore requests such as health checks.
+type AccessLog struct {
+	Writer  io.Writer
+	Exclude []string
+	Sample  float64
+	Format  format
+	lock    sync.Mutex
+}
+type record struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Route    string        `json:"route,omitempty"`
+	Remote   string        `json:"remote"`
+	Agent    string        `json:"agent,omitempty"`
+	ID       string        `json:"id,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Bytes    int64         `json:"bytes"`
+	Status   int           `json:"status"`
+}
+
+func remote(s string) string {
+	if h, _, err := net.SplitHostPort(s); err == nil {
+		return h
+	}
+	return s
+}
+func quote(s string) string {
+	if len(s) == 0 {
+		return `""`
+	}
+	if strings.ContainsAny(s, " \t\r\n\"=\\") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+func (a *AccessLog) skip(r *http.Request) bool {
+	for i := range a.Exclude {
+		if strings.HasPrefix(r.URL.Path, a.Exclude[i]) {
+			return true
+		}
+	}
+	return a.Sample > 0 && a.Sample < 1 && rand.Float64() >= a.Sample
+}
+func (a *AccessLog) write(w *writer, r *http.Request, route, id string) {
+	if a == nil || a.Writer == nil || a.skip(r) {
+		return
+	}
+	v := record{
+		Time:     w.t,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Remote:   remote(r.RemoteAddr),
+		Agent:    r.UserAgent(),
+		Route:    route,
+		ID:       id,
+		Duration: w.Duration(),
+		Bytes:    w.n,
+		Status:   w.c,
+	}
+	if v.Status == 0 {
+		v.Status = http.StatusOK
+	}
+	var b []byte
+	switch a.Format {
+	case LogFmt:
+		b = v.logfmt()
+	case LogJSON:
+		b, _ = json.Marshal(v)
+		b = append(b, '\n')
+	default:
+		b = v.common(r, a.Format == LogCombined)
+	}
+	a.lock.Lock()
+	a.Writer.Write(b)
+	a.lock.Unlock()
+}
+func (v record) logfmt() []byte {
+	return []byte(
+		"time=" + v.Time.Format(time.RFC3339) + " method=" + quote(v.Method) + " path=" + quote(v.Path) +
+			" route=" + quote(v.Route) + " status=" + strconv.Itoa(v.Status) + " bytes=" + strconv.FormatInt(v.Bytes, 10) +
+			" duration=" + v.Duration.String() + " remote=" + quote(v.Remote) + " agent=" + quote(v.Agent) +
+			" id=" + quote(v.ID) + "\n",
+	)
+}
+func (v record) common(r *http.Request, c bool) []byte {
+	u := "-"
+	if n, _, ok := r.BasicAuth(); ok && len(n) > 0 {
+		u = n
+	}
+	n := "-"
+	if v.Bytes > 0 {
+		n = strconv.FormatInt(v.Bytes, 10)
+	}
+	s := v.Remote + " - " + u + " [" + v.Time.Format("02/Jan/2006:15:04:05 -0700") + `] "` + r.Method + " " +
+		r.URL.RequestURI() + " " + r.Proto + `" ` + strconv.Itoa(v.Status) + " " + n
+	if c {
+		s += " " + strconv.Quote(dash(r.Referer())) + " " + strconv.Quote(dash(v.Agent))
+	}
+	return []byte(s + "\n")
+}
@@ -0,0 +1,313 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+const (
+	// IssueUnanchored is an Issue kind that indicates that the route expression
+	// is not anchored with '^' at the start or '$' at the end, so it may match
+	// paths that contain or start with the expression.
+	IssueUnanchored issue = iota
+	// IssueShadowed is an Issue kind that indicates that every sample path of
+	// the route expression is matched by a route that is evaluated before it, so
+	// the route is most likely unreachable.
+	IssueShadowed
+	// IssueOverlap is an Issue kind that indicates that some, but not all, of
+	// the sample paths of the route expression are matched by a route that is
+	// evaluated before it.
+	IssueOverlap
+	// IssueDuplicate is an Issue kind that indicates that a Route was added to a
+	// path (and methods) that already had a Handler without match conditions and
+	// no condition was added to it, so it is never used.
+	IssueDuplicate
+)
+
+// samples is the amount of sample paths generated for each route expression.
+const samples = 4
+
+// preferred is the list of runes that are picked first when generating a sample
+// from a character class, as they make the most realistic paths.
+const preferred = "ab01xz-_."
+
+type issue uint8
+
+// Issue is a struct that describes a problem found with a route when using the
+// Mux 'Check' function or when adding routes to a Mux with 'Strict' enabled.
+//
+// Path is the expression of the route with the problem and Other is the expression
+// of the route that caused it (if any).
+//
+// Issues are found by probing each route with sample paths generated from its
+// expression, so they may not find every problem and should be treated as
+// warnings.
+type Issue struct {
+	Path  string `json:"path"`
+	Other string `json:"other,omitempty"`
+	Kind  issue  `json:"kind"`
+}
+
+// Check analyzes the routes of this Mux in their evaluation order and returns
+// a list of any Issues found. An empty list is returned if no Issues are found.
+//
+// Routes are checked for missing anchors, duplicate Handlers that never had a
+// match condition added, being completely shadowed by an earlier route and
+// overlapping an earlier route. Routes with match conditions are never
+// considered to shadow or overlap another route.
+func (m *Mux) Check() []Issue {
+	return m.load().check()
+}
+func (i issue) String() string {
+	switch i {
+	case IssueUnanchored:
+		return "unanchored"
+	case IssueShadowed:
+		return "shadowed"
+	case IssueOverlap:
+		return "overlap"
+	case IssueDuplicate:
+		return "duplicate"
+	}
+	return "invalid"
+}
+
+// MarshalText allows the Issue kind to be written as its name.
+func (i issue) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+func (i Issue) Error() string {
+	switch i.Kind {
+	case IssueUnanchored:
+		return `route "` + i.Path + `" is not anchored with '^' and '$'`
+	case IssueShadowed:
+		return `route "` + i.Path + `" is shadowed by route "` + i.Other + `"`
+	case IssueDuplicate:
+		return `route "` + i.Path + `" has a duplicate Handler without match conditions`
+	}
+	return `route "` + i.Path + `" overlaps route "` + i.Other + `"`
+}
+func (i Issue) String() string {
+	return i.Error()
+}
+func (e *entry) anchoredEnd() bool {
+	r, err := syntax.Parse(e.matcher.String(), syntax.Perl)
+	if err != nil {
+		return false
+	}
+	if r = r.Simplify(); r.Op == syntax.OpEndText {
+		return true
+	}
+	return r.Op == syntax.OpConcat && len(r.Sub) > 0 && r.Sub[len(r.Sub)-1].Op == syntax.OpEndText
+}
+func (e *entry) samples() []string {
+	r, err := syntax.Parse(e.matcher.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	var (
+		l = make([]string, 0, samples)
+		b strings.Builder
+	)
+	r = r.Simplify()
+	for v := 0; v < samples; v++ {
+		b.Reset()
+		sample(&b, r, v)
+		s := b.String()
+		if !e.matcher.MatchString(s) {
+			continue
+		}
+		var f bool
+		for i := range l {
+			if f = l[i] == s; f {
+				break
+			}
+		}
+		if !f {
+			l = append(l, s)
+		}
+	}
+	return l
+}
+
+// covers returns true if this entry will handle every method the supplied entry
+// handles without any match conditions.
+func (e *entry) covers(o *entry) bool {
+	if e.base != nil {
+		return true
+	}
+	if o.base != nil || len(o.match) > 0 {
+		return false
+	}
+	for n := range o.method {
+		if _, ok := e.method[n]; !ok {
+			return false
+		}
+	}
+	return true
+}
+func (e *entry) overlaps(o *entry) bool {
+	if e.base != nil || o.base != nil {
+		return e.base != nil || len(e.method) > 0
+	}
+	for n := range o.methods() {
+		if _, ok := e.method[n]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// probe returns the kind of Issue the route at position i causes for the later
+// route at position j, using the supplied samples of the later route. The boolean
+// is false if the route does not match any of the samples.
+func (t *table) probe(i, j int, l []string) (issue, bool) {
+	if t.routes[i].host != nil && !sameHost(t.routes[i].host, t.routes[j].host) {
+		return 0, false
+	}
+	if !t.routes[i].overlaps(t.routes[j]) {
+		return 0, false
+	}
+	var c int
+	for z := range l {
+		if t.routes[i].matcher.MatchString(l[z]) {
+			c++
+		}
+	}
+	if c == 0 {
+		return 0, false
+	}
+	if c == len(l) && t.routes[i].covers(t.routes[j]) {
+		return IssueShadowed, true
+	}
+	return IssueOverlap, true
+}
+func (t *table) check() []Issue {
+	var r []Issue
+	for j := range t.routes {
+		if !t.routes[j].anchored || !t.routes[j].anchoredEnd() {
+			r = append(r, Issue{Path: t.routes[j].matcher.String(), Kind: IssueUnanchored})
+		}
+		for _, h := range t.routes[j].match {
+			if h.wait {
+				r = append(r, Issue{Path: t.routes[j].matcher.String(), Kind: IssueDuplicate})
+			}
+		}
+		l := t.routes[j].samples()
+		if len(l) == 0 {
+			continue
+		}
+		for i := 0; i < j; i++ {
+			k, ok := t.probe(i, j, l)
+			if !ok {
+				continue
+			}
+			r = append(r, Issue{Path: t.routes[j].matcher.String(), Other: t.routes[i].matcher.String(), Kind: k})
+			if k == IssueShadowed {
+				break
+			}
+		}
+	}
+	return r
+}
+
+// strict returns the first Issue that is not an overlap involving the entry with
+// the supplied ID as an error. Only the entry is checked against the routes that
+// are evaluated before and after it, instead of checking the entire table.
+func (t *table) strict(id uint64) error {
+	k := t.find(id)
+	if k == -1 {
+		return nil
+	}
+	p := t.routes[k].matcher.String()
+	if !t.routes[k].anchored || !t.routes[k].anchoredEnd() {
+		return Issue{Path: p, Kind: IssueUnanchored}
+	}
+	if l := t.routes[k].samples(); len(l) > 0 {
+		for i := 0; i < k; i++ {
+			if v, _ := t.probe(i, k, l); v == IssueShadowed {
+				return Issue{Path: p, Other: t.routes[i].matcher.String(), Kind: v}
+			}
+		}
+	}
+	for j := k + 1; j < len(t.routes); j++ {
+		if v, _ := t.probe(k, j, t.routes[j].samples()); v == IssueShadowed {
+			return Issue{Path: t.routes[j].matcher.String(), Other: p, Kind: v}
+		}
+	}
+	return nil
+}
+func pick(r []rune, v int) rune {
+	var c []rune
+	for _, x := range preferred {
+		for i := 0; i+1 < len(r); i += 2 {
+			if x >= r[i] && x <= r[i+1] {
+				c = append(c, x)
+				break
+			}
+		}
+	}
+	if len(c) > 0 {
+		return c[v%len(c)]
+	}
+	for i := 0; i+1 < len(r); i += 2 {
+		for x := r[i]; x <= r[i+1] && x < r[i]+samples; x++ {
+			if x != '/' && unicode.IsPrint(x) && !unicode.IsSpace(x) {
+				return x
+			}
+		}
+	}
+	if len(r) > 0 {
+		return r[0]
+	}
+	return 'a'
+}
+func sample(b *strings.Builder, r *syntax.Regexp, v int) {
+	switch r.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(r.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(pick(r.Rune, v))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte(preferred[v%len(preferred)])
+	case syntax.OpCapture:
+		sample(b, r.Sub[0], v)
+	case syntax.OpConcat:
+		for i := range r.Sub {
+			sample(b, r.Sub[i], v)
+		}
+	case syntax.OpAlternate:
+		sample(b, r.Sub[v%len(r.Sub)], v)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		n := v
+		switch r.Op {
+		case syntax.OpPlus:
+			n = v + 1
+		case syntax.OpQuest:
+			n = v % 2
+		case syntax.OpRepeat:
+			if n = r.Min + v; r.Max >= 0 && n > r.Max {
+				n = r.Max
+			}
+		}
+		for i := 0; i < n; i++ {
+			sample(b, r.Sub[0], v)
+		}
+	}
+}
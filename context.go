@@ -0,0 +1,56 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"time"
+)
+
+// merged is a Context that is canceled when either the request Context or the
+// Mux base Context is done. Values are looked up in the request Context first.
+type merged struct {
+	context.Context
+	b context.Context
+}
+
+// merge returns a Context that combines the supplied request Context with the
+// Mux base Context and a function that must be called once the request is done.
+// The request Context is returned as-is if the Mux does not have a base Context.
+func (m *Mux) merge(x context.Context) (context.Context, func()) {
+	if m.ctx == nil || m.ctx == context.Background() || m.ctx == x {
+		return x, func() {}
+	}
+	c, f := context.WithCancelCause(x)
+	s := context.AfterFunc(m.ctx, func() { f(context.Cause(m.ctx)) })
+	return merged{Context: c, b: m.ctx}, func() {
+		s()
+		f(context.Canceled)
+	}
+}
+func (c merged) Value(k any) any {
+	if v := c.Context.Value(k); v != nil {
+		return v
+	}
+	return c.b.Value(k)
+}
+func (c merged) Deadline() (time.Time, bool) {
+	d, ok := c.Context.Deadline()
+	if v, k := c.b.Deadline(); k && (!ok || v.Before(d)) {
+		return v, true
+	}
+	return d, ok
+}
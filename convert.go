@@ -18,11 +18,15 @@ package routex
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 type convert struct {
 	http.Handler
 }
+type adapter struct {
+	ErrorHandler
+}
 
 // ConvertFunc is an alias for the standard 'http.HandlerFunc' that can be used
 // for compatibility with any built-in interface support.
@@ -32,9 +36,21 @@ type ConvertFunc http.HandlerFunc
 type Func func(context.Context, http.ResponseWriter, *Request)
 
 // ErrorFunc is an alias that can be used to use a function signature as a 'ErrorHandler'
-// instead.
+// or 'ErrorResponder' instead.
 type ErrorFunc func(int, string, http.ResponseWriter, *Request)
 
+// ResponderFunc is an alias that can be used to use a function signature as a
+// 'ErrorResponder' instead.
+type ResponderFunc func(error, http.ResponseWriter, *Request)
+
+// PanicFunc is an alias that can be used to use a function signature as a 'PanicHandler'
+// instead.
+type PanicFunc func(context.Context, *Request, any, []byte)
+
+// SlowFunc is an alias that can be used to use a function signature as a 'SlowHandler'
+// instead.
+type SlowFunc func(context.Context, *Request, time.Duration, time.Duration)
+
 // WrapFunc is an alias that can be used to use a function signature as a 'Wrapper'
 // instead.
 type WrapFunc func(context.Context, http.ResponseWriter, *Request, Content)
@@ -49,6 +65,13 @@ func Convert(h http.Handler) Handler {
 	return convert{Handler: h}
 }
 
+// AdaptError is a wrapper for the legacy 'ErrorHandler' interface that can be
+// used to use it as an 'ErrorResponder'. The ErrorHandler will be called with the
+// status and public message of the error.
+func AdaptError(h ErrorHandler) ErrorResponder {
+	return adapter{ErrorHandler: h}
+}
+
 // Handle allows this alias to fulfill the Handler interface.
 func (f Func) Handle(x context.Context, w http.ResponseWriter, r *Request) {
 	f(x, w, r)
@@ -69,6 +92,33 @@ func (f ErrorFunc) HandleError(c int, s string, w http.ResponseWriter, r *Reques
 	f(c, s, w, r)
 }
 
+// RespondError allows this alias to fulfill the ErrorResponder interface.
+func (f ErrorFunc) RespondError(err error, w http.ResponseWriter, r *Request) {
+	e := errorOf(http.StatusInternalServerError, err)
+	f(e.Status, e.Message, w, r)
+}
+
+// RespondError allows this alias to fulfill the ErrorResponder interface.
+func (f ResponderFunc) RespondError(err error, w http.ResponseWriter, r *Request) {
+	f(err, w, r)
+}
+
+// RespondError allows this adapter to fulfill the ErrorResponder interface.
+func (a adapter) RespondError(err error, w http.ResponseWriter, r *Request) {
+	e := errorOf(http.StatusInternalServerError, err)
+	a.HandleError(e.Status, e.Message, w, r)
+}
+
+// HandlePanic allows this alias to fulfill the PanicHandler interface.
+func (f PanicFunc) HandlePanic(x context.Context, r *Request, v any, s []byte) {
+	f(x, r, v, s)
+}
+
+// HandleSlow allows this alias to fulfill the SlowHandler interface.
+func (f SlowFunc) HandleSlow(x context.Context, r *Request, d, e time.Duration) {
+	f(x, r, d, e)
+}
+
 // Handle allows this alias to fulfill the Wrapper interface.
 func (f WrapFunc) Handle(x context.Context, w http.ResponseWriter, r *Request, c Content) {
 	f(x, w, r, c)
@@ -0,0 +1,185 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS is a struct that describes a Cross-Origin Resource Sharing policy. A
+// CORS policy can be set on a Mux (using the 'CORS' field) or on a Route (using
+// the 'CORS' function), where the Route policy takes precedence.
+//
+// When a policy applies, the Mux will answer preflight requests using the methods
+// the matched path actually handles and will add the CORS Headers to the actual
+// responses.
+//
+// Origins may contain exact origins, "*" to allow any origin or a single wildcard
+// such as "https://*.example.com". The Patterns list can be used to allow origins
+// using regex expressions. If both are empty, any origin is allowed.
+//
+// If Methods is empty, all the methods of the matched path are allowed. If Headers
+// is empty, the headers requested in the preflight are allowed.
+//
+// Credentials are only allowed for an explicit list of Origins or Patterns. The
+// Credentials option is ignored if any origin is allowed, as it would let any
+// site make requests using the credentials of the user.
+type CORS struct {
+	Origins     []string
+	Patterns    []*regexp.Regexp
+	Methods     []string
+	Headers     []string
+	Expose      []string
+	MaxAge      time.Duration
+	Credentials bool
+}
+
+func preflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && len(r.Header.Get("Origin")) > 0 && len(r.Header.Get("Access-Control-Request-Method")) > 0
+}
+func (r *route) CORS(c *CORS) Route {
+	return r.change(func(_ *entry, h *handler) {
+		h.cors = c
+	})
+}
+func (c *CORS) any() bool {
+	if len(c.Origins) == 0 && len(c.Patterns) == 0 {
+		return true
+	}
+	for i := range c.Origins {
+		if c.Origins[i] == "*" {
+			return true
+		}
+	}
+	return false
+}
+func (c *CORS) allowed(o string) bool {
+	if c.any() {
+		return true
+	}
+	for _, v := range c.Origins {
+		if strings.EqualFold(v, o) {
+			return true
+		}
+		i := strings.IndexByte(v, '*')
+		if i == -1 || len(o) < len(v)-1 {
+			continue
+		}
+		if strings.HasPrefix(o, v[:i]) && strings.HasSuffix(o, v[i+1:]) {
+			return true
+		}
+	}
+	for i := range c.Patterns {
+		if c.Patterns[i].MatchString(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// origin writes the Headers shared by preflight and actual responses and returns
+// false if the request Origin is not allowed.
+func (c *CORS) origin(w http.ResponseWriter, r *http.Request) bool {
+	o := r.Header.Get("Origin")
+	if len(o) == 0 || !c.allowed(o) {
+		return false
+	}
+	if c.any() {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return true
+	}
+	w.Header().Set("Access-Control-Allow-Origin", o)
+	if w.Header().Add("Vary", "Origin"); c.Credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+func (c *CORS) apply(w http.ResponseWriter, r *http.Request) {
+	if !c.origin(w, r) {
+		return
+	}
+	if len(c.Expose) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.Expose, ", "))
+	}
+}
+func (c *CORS) preflight(w http.ResponseWriter, r *http.Request, a []string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	var (
+		n = r.Header.Get("Access-Control-Request-Method")
+		l = a
+	)
+	if len(c.Methods) > 0 {
+		l = make([]string, 0, len(c.Methods))
+		for i := range c.Methods {
+			for x := range a {
+				if a[x] == c.Methods[i] {
+					l = append(l, c.Methods[i])
+					break
+				}
+			}
+		}
+	}
+	var f bool
+	for i := range l {
+		if f = l[i] == n; f {
+			break
+		}
+	}
+	if !f || !c.origin(w, r) {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(l, ", "))
+	if len(c.Headers) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.Headers, ", "))
+	} else if v := r.Header.Get("Access-Control-Request-Headers"); len(v) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", v)
+	}
+	if c.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.FormatInt(int64(c.MaxAge/time.Second), 10))
+	}
+}
+
+// policy returns the CORS policy of the Handler that would handle the supplied
+// method on this entry, or the supplied Mux policy if the Handler does not have
+// one.
+func (e *entry) policy(s string, d *CORS) *CORS {
+	h := e.method[s]
+	if h == nil {
+		for _, v := range e.match {
+			if !v.wait && v.handles(s) {
+				h = v
+				break
+			}
+		}
+	}
+	if h == nil && s == http.MethodHead {
+		if h = e.method[http.MethodGet]; h != nil && h.nohead {
+			h = nil
+		}
+	}
+	if h == nil {
+		h = e.base
+	}
+	if h != nil && h.cors != nil {
+		return h.cors
+	}
+	return d
+}
@@ -0,0 +1,163 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type debug struct {
+	m *Mux
+}
+
+// Param is a struct that describes a parameter of a route added using the
+// 'AddPattern' functions.
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// RouteInfo is a struct that describes a route added to a Mux. These can be
+// retrieved using the 'Routes' function.
+//
+// The Path value is the regex expression used to match the request path, while
+// Pattern is only set for routes added using the 'AddPattern' functions. Routes
+// that only have a Host value are Host defaults added using 'DefaultHost'.
+type RouteInfo struct {
+	Name        string   `json:"name,omitempty"`
+	Host        string   `json:"host,omitempty"`
+	Path        string   `json:"path,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+	Params      []Param  `json:"params,omitempty"`
+	Methods     []string `json:"methods,omitempty"`
+	Priority    int      `json:"priority"`
+	Middleware  int      `json:"middleware"`
+	Conditional int      `json:"conditional"`
+	Base        bool     `json:"base"`
+}
+
+// Routes returns a list describing all the routes added to this Mux in the order
+// they will be evaluated in, followed by any Host defaults.
+//
+// The returned list is a snapshot and is not affected by any further changes to
+// the Mux routes.
+func (m *Mux) Routes() []RouteInfo {
+	var (
+		t = m.load()
+		r = make([]RouteInfo, 0, len(t.routes)+len(t.hosts))
+	)
+	for _, l := range [...][]*entry{t.routes, t.hosts} {
+		for _, e := range l {
+			r = append(r, e.info())
+		}
+	}
+	return r
+}
+
+// DebugHandler returns a Handler that will write the routes of this Mux as a
+// table. The routes will be written as JSON when requested by the 'Accept'
+// Header or the 'format=json' URL query value, otherwise as plain text.
+//
+// This Handler can be added to any path, but it is recommended that it is not
+// exposed publicly.
+func (m *Mux) DebugHandler() Handler {
+	return &debug{m: m}
+}
+func (e *entry) info() RouteInfo {
+	i := RouteInfo{Name: e.name, Pattern: e.pattern, Priority: e.prio, Base: e.base != nil}
+	if e.host != nil {
+		i.Host = e.host.String()
+	}
+	if e.matcher != nil {
+		i.Path = e.matcher.String()
+		for _, n := range e.matcher.SubexpNames() {
+			if len(n) > 0 {
+				i.Groups = append(i.Groups, n)
+			}
+		}
+	}
+	if len(e.params) > 0 {
+		i.Params = make([]Param, len(e.params))
+		for z := range e.params {
+			i.Params[z] = Param{Name: e.params[z].name, Type: e.params[z].kind}
+		}
+	}
+	for n := range e.methods() {
+		i.Methods = append(i.Methods, n)
+	}
+	sort.Strings(i.Methods)
+	i.Conditional = len(e.match)
+	if e.base != nil {
+		i.Middleware += e.base.middlewares()
+	}
+	// A Handler added for multiple methods is stored for each method, so only
+	// count it once.
+	c := make(map[*handler]bool, len(e.method))
+	for _, h := range e.method {
+		if !c[h] {
+			c[h] = true
+			i.Middleware += h.middlewares()
+		}
+	}
+	for _, h := range e.match {
+		i.Middleware += h.middlewares()
+	}
+	return i
+}
+func (h *handler) middlewares() int {
+	if h.wares == nil {
+		return 0
+	}
+	h.wares.lock.RLock()
+	n := len(h.wares.w) + len(h.wares.c)
+	h.wares.lock.RUnlock()
+	return n
+}
+func (d *debug) Handle(_ context.Context, w http.ResponseWriter, r *Request) {
+	v := d.m.Routes()
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		JSON(w, http.StatusOK, v)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	t := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	t.Write([]byte("#\tNAME\tHOST\tPATH\tMETHODS\tBASE\tPRIORITY\tMIDDLEWARE\n"))
+	for i := range v {
+		b := "-"
+		if v[i].Base {
+			b = "yes"
+		}
+		t.Write([]byte(
+			strconv.Itoa(i) + "\t" + dash(v[i].Name) + "\t" + dash(v[i].Host) + "\t" + dash(v[i].Path) + "\t" +
+				dash(strings.Join(v[i].Methods, ",")) + "\t" + b + "\t" + strconv.Itoa(v[i].Priority) + "\t" +
+				strconv.Itoa(v[i].Middleware) + "\n",
+		))
+	}
+	t.Flush()
+}
+func dash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
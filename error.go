@@ -15,7 +15,52 @@
 
 package routex
 
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+const (
+	// CodeNoBody is the Error code used when a Handler expected a request body,
+	// but none was supplied.
+	CodeNoBody = "no_body"
+	// CodeInvalidJSON is the Error code used when the request body could not be
+	// parsed as JSON.
+	CodeInvalidJSON = "invalid_json"
+	// CodeInvalidContent is the Error code used when the request body was parsed,
+	// but failed validation.
+	CodeInvalidContent = "invalid_content"
+)
+
+// Error is a struct that describes an error response. Errors are passed to the
+// ErrorResponders of a Mux, either created by the Mux or returned by a Handler or
+// Validator.
+//
+// Status is the HTTP status code and Message is the public message that is safe
+// to show to clients. Err is the internal cause (if any), which should not be
+// shown to clients. Code is an optional machine-readable error code and Fields
+// is an optional list of per-field problems, such as validation failures.
+type Error struct {
+	Err     error
+	Message string
+	Code    string
+	Fields  []Field
+	Status  int
+}
+
+// Field is a struct that describes a problem with a single field of the request
+// content, such as a validation failure.
+type Field struct {
+	Name    string `json:"field"`
+	Message string `json:"message"`
+}
 type errStr string
+type fielder interface {
+	Field() string
+	Reason() string
+}
 type errValue struct {
 	e error
 	s string
@@ -39,3 +84,72 @@ func (e errValue) Unwrap() error {
 func (e errValue) String() string {
 	return e.Error()
 }
+
+// NewError returns a new Error with the supplied status, public message and
+// internal cause. If the message is empty, the status text is used instead.
+func NewError(c int, s string, err error) *Error {
+	if len(s) == 0 {
+		s = http.StatusText(c)
+	}
+	return &Error{Status: c, Message: s, Err: err}
+}
+
+// RespondError will report the supplied error to the client using the Mux
+// ErrorResponders. If the error is not an '*Error', it will be reported with
+// a 500 status and a generic message.
+//
+// This allows Handlers to report errors the same way the Mux does and allows the
+// Mux to be used as an ErrorResponder.
+func (m *Mux) RespondError(err error, w http.ResponseWriter, r *Request) {
+	m.handleError(errorOf(http.StatusInternalServerError, err), w, r)
+}
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorOf returns the supplied error as an Error. If the error is not an Error,
+// a new Error with the supplied status and the error as its cause is returned.
+func errorOf(c int, err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Status == 0 {
+			v := *e
+			v.Status = c
+			return &v
+		}
+		return e
+	}
+	return NewError(c, "", err)
+}
+
+// invalid returns an Error with a 400 status from an error returned while reading
+// or validating a request body.
+func invalid(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return errorOf(http.StatusBadRequest, e)
+	}
+	var (
+		x *json.SyntaxError
+		t *json.UnmarshalTypeError
+		v = &Error{Status: http.StatusBadRequest, Message: err.Error(), Err: err, Code: CodeInvalidContent}
+	)
+	var f fielder
+	switch {
+	case errors.Is(err, ErrNoBody):
+		v.Code = CodeNoBody
+	case errors.As(err, &x), errors.As(err, &t), errors.Is(err, io.ErrUnexpectedEOF):
+		v.Code = CodeInvalidJSON
+	case errors.As(err, &f):
+		// This matches the 'FieldError' type of the 'val' package, which cannot be
+		// imported here.
+		v.Fields = []Field{{Name: f.Field(), Message: f.Reason()}}
+	}
+	return v
+}
@@ -56,11 +56,12 @@ func verify(_ context.Context, w http.ResponseWriter, r *routex.Request) bool {
 
 func main() {
 	var (
-		m routex.Mux
-		s = &http.Server{Addr: "127.0.0.1:8080", Handler: &m}
+		m = routex.New()
+		s = routex.NewServer("127.0.0.1:8080", m)
 	)
 
 	m.SetLog(f(true))
+	s.Health = true
 
 	m.Middleware(alwaysJSON)
 
@@ -71,7 +72,7 @@ func main() {
 	m.Must(`^/val`, routex.Marshal[structY](nil, routex.MarshalFunc[structY](funcY)))
 
 	if err := s.ListenAndServe(); err != nil {
-		panic(err)
+		fmt.Println("server error:", err)
 	}
 }
 
@@ -1,3 +1,3 @@
 module github.com/PurpleSec/routex
 
-go 1.18
+go 1.21
@@ -0,0 +1,186 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidMux is returned from the 'Mount' functions when the supplied Mux is
+// nil or is the Mux being mounted on.
+const ErrInvalidMux = errStr("cannot mount a nil or parent Mux")
+
+type mount struct {
+	m *Mux
+	n int
+}
+
+// Group is a route registrar that adds a shared path prefix and Middleware to
+// any routes added through it. Groups can be created with the 'Group' function
+// on a Mux or another Group.
+//
+// Regex paths added to a Group are always anchored to the Group prefix. The '^'
+// character at the start of the path is optional and the rest of the expression
+// must match directly after the prefix.
+type Group struct {
+	m      *Mux
+	prefix string
+	wares  []Middleware
+}
+
+// rawPrefix returns the length of the start of the escaped path that unescapes
+// to the first n bytes of the path.
+func rawPrefix(s string, n int) int {
+	var i int
+	for ; n > 0 && i < len(s); n-- {
+		if s[i] == '%' && i+2 < len(s) {
+			i += 3
+		} else {
+			i++
+		}
+	}
+	return i
+}
+func prefixPath(s string) string {
+	if len(s) == 0 || s == "/" {
+		return ""
+	}
+	if s[0] != '/' {
+		s = "/" + s
+	}
+	return strings.TrimRight(s, "/")
+}
+
+// Group returns a new Group that will add the supplied prefix to the path of any
+// routes added to it. The supplied Middleware will be applied to every route
+// added, before any route-specific Middleware.
+func (m *Mux) Group(prefix string, w ...Middleware) *Group {
+	return &Group{m: m, prefix: prefixPath(prefix), wares: w}
+}
+
+// Mount will delegate all requests with a path that starts with the supplied
+// prefix to the supplied Mux. The prefix will be removed from the path passed to
+// the mounted Mux and any Values captured by this Mux will be merged into the
+// Request Values of the mounted Mux.
+//
+// This function returns an error if the Mux is nil, is this Mux or the prefix
+// has already been mounted.
+func (m *Mux) Mount(prefix string, sub *Mux) (Route, error) {
+	if sub == nil || sub == m {
+		return nil, ErrInvalidMux
+	}
+	p := prefixPath(prefix)
+	x, err := regexp.Compile("^" + regexp.QuoteMeta(p) + "(/.*)?$")
+	if err != nil {
+		return nil, &errValue{s: `mount "` + p + `" compile`, e: err}
+	}
+	return m.add(&entry{matcher: x}, nil, &mount{m: sub, n: len(p)}, false)
+}
+
+// Group returns a new Group nested in this Group. The new Group prefix will be
+// appended to this Group prefix and this Group's Middleware will be applied before
+// the supplied Middleware.
+func (g *Group) Group(prefix string, w ...Middleware) *Group {
+	n := make([]Middleware, 0, len(g.wares)+len(w))
+	n = append(append(n, g.wares...), w...)
+	return &Group{m: g.m, prefix: g.prefix + prefixPath(prefix), wares: n}
+}
+
+// Mount will delegate all requests with a path that starts with this Group prefix
+// and the supplied prefix to the supplied Mux. This function acts the same as
+// the Mux 'Mount' function, but also applies the Group Middleware.
+func (g *Group) Mount(prefix string, sub *Mux) (Route, error) {
+	r, err := g.m.Mount(g.prefix+prefixPath(prefix), sub)
+	if err != nil {
+		return nil, err
+	}
+	return r.Middleware(g.wares...), nil
+}
+
+// Must adds the Handler to the supplied regex expression path, prefixed with the
+// Group prefix. This function acts the same as the Mux 'Must' function.
+//
+// This function panics if the regex expression is invalid.
+func (g *Group) Must(path string, h Handler, methods ...string) Route {
+	v, err := g.Add(path, h, methods...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return v
+}
+
+// Add adds the Handler to the supplied regex expression path, prefixed with the
+// Group prefix. This function acts the same as the Mux 'Add' function.
+//
+// This function returns an error if the regex expression is invalid.
+func (g *Group) Add(path string, h Handler, methods ...string) (Route, error) {
+	if len(path) == 0 {
+		return nil, ErrInvalidPath
+	}
+	if path[0] == '^' {
+		path = path[1:]
+	}
+	r, err := g.m.Add("^"+regexp.QuoteMeta(g.prefix)+"(?:"+path+")", h, methods...)
+	if err != nil {
+		return nil, err
+	}
+	return r.Middleware(g.wares...), nil
+}
+
+// MustPattern adds the Handler to the supplied declarative pattern, prefixed with
+// the Group prefix. This function acts the same as the Mux 'MustPattern' function.
+//
+// This function panics if the pattern is invalid.
+func (g *Group) MustPattern(pattern string, h Handler, methods ...string) Route {
+	v, err := g.AddPattern(pattern, h, methods...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return v
+}
+
+// AddPattern adds the Handler to the supplied declarative pattern, prefixed with
+// the Group prefix. This function acts the same as the Mux 'AddPattern' function.
+//
+// This function returns an error if the pattern is invalid.
+func (g *Group) AddPattern(pattern string, h Handler, methods ...string) (Route, error) {
+	if len(pattern) == 0 {
+		return nil, ErrInvalidPath
+	}
+	r, err := g.m.AddPattern(g.prefix+pattern, h, methods...)
+	if err != nil {
+		return nil, err
+	}
+	return r.Middleware(g.wares...), nil
+}
+func (v *mount) Handle(x context.Context, w http.ResponseWriter, r *Request) {
+	var (
+		q = new(http.Request)
+		u = *r.URL
+	)
+	*q = *r.Request
+	if u.Path, u.RawPath = r.URL.Path[v.n:], ""; len(r.URL.RawPath) > 0 {
+		u.RawPath = r.URL.RawPath[rawPrefix(r.URL.RawPath, v.n):]
+	}
+	if len(u.Path) == 0 {
+		u.Path = "/"
+	}
+	q.URL = &u
+	v.m.serve(x, w, q, r.Values)
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGroupAnchored(t *testing.T) {
+	m := New()
+	m.Group("/api").Must("/a$|/b$", Func(func(_ context.Context, w http.ResponseWriter, _ *Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	for p, c := range map[string]int{
+		"/api/a": http.StatusAccepted,
+		"/api/b": http.StatusAccepted,
+		"/b":     http.StatusNotFound,
+		"/x/b":   http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		if m.ServeHTTP(w, httptest.NewRequest("GET", p, nil)); w.Code != c {
+			t.Fatalf("path %q returned status %d, expected %d", p, w.Code, c)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import "regexp"
+
+func sameHost(a, b *regexp.Regexp) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
+
+// MustHost adds the Handler to the supplied regex expression path that will only
+// be matched when the request Host (without the port) also matches the supplied
+// host regex expression. Host values are matched in lowercase.
+//
+// Regex match groups in both expressions can be used to grab data out of the call
+// and will be placed in the 'Values' Request map. Path values will override any
+// Host values with the same name.
+//
+// This function panics if either regex expression is invalid.
+func (m *Mux) MustHost(host, path string, h Handler, methods ...string) Route {
+	v, err := m.AddHost(host, path, h, methods...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return v
+}
+
+// AddHost adds the Handler to the supplied regex expression path that will only
+// be matched when the request Host (without the port) also matches the supplied
+// host regex expression. Host values are matched in lowercase.
+//
+// Regex match groups in both expressions can be used to grab data out of the call
+// and will be placed in the 'Values' Request map. Path values will override any
+// Host values with the same name.
+//
+// Routes with a Host expression are evaluated before routes without one that
+// have the same priority and specificity.
+//
+// This function returns an error if either regex expression is invalid.
+func (m *Mux) AddHost(host, path string, h Handler, methods ...string) (Route, error) {
+	if len(host) == 0 || len(path) == 0 {
+		return nil, ErrInvalidPath
+	}
+	if h == nil {
+		return nil, ErrInvalidHandler
+	}
+	o, err := regexp.Compile(host)
+	if err != nil {
+		return nil, &errValue{s: `host "` + host + `" compile`, e: err}
+	}
+	x, err := regexp.Compile(path)
+	if err != nil {
+		return nil, &errValue{s: `path "` + path + `" compile`, e: err}
+	}
+	return m.add(&entry{host: o, matcher: x}, methods, h, false)
+}
+
+// DefaultHost sets the Handler that will be used when the request Host (without
+// the port) matches the supplied host regex expression, but no route matches
+// the request. Host defaults are checked in the order they are added and before
+// the Mux 'Default' Handler.
+//
+// Regex match groups can be used to grab data out of the Host and will be placed
+// in the 'Values' Request map.
+//
+// This function returns an error if a default for the host already exists or
+// the regex expression is invalid.
+func (m *Mux) DefaultHost(host string, h Handler) error {
+	if len(host) == 0 {
+		return ErrInvalidPath
+	}
+	if h == nil {
+		return ErrInvalidHandler
+	}
+	x, err := regexp.Compile(host)
+	if err != nil {
+		return &errValue{s: `host "` + host + `" compile`, e: err}
+	}
+	m.lock.Lock()
+	err = m.update(func(t *table) error {
+		for i := range t.hosts {
+			if t.hosts[i].host.String() == host {
+				return errStr(`default host "` + host + `" already exists`)
+			}
+		}
+		m.seq++
+		e := &entry{host: x, seq: m.seq}
+		e.base = &handler{h: h, c: h}
+		t.hosts = append(t.hosts, e)
+		return nil
+	})
+	m.lock.Unlock()
+	return err
+}
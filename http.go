@@ -17,29 +17,52 @@ package routex
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
+// standard is the list of methods allowed by a base Handler.
+var standard = [...]string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
 type wares struct {
 	lock sync.RWMutex
+	h    Handler
 	w    []Middleware
+	c    []Chain
 }
 type entry struct {
-	base    *handler
-	method  map[string]*handler
-	matcher *regexp.Regexp
+	base     *handler
+	method   map[string]*handler
+	match    []*handler
+	host     *regexp.Regexp
+	matcher  *regexp.Regexp
+	name     string
+	pattern  string
+	prefix   string
+	params   []param
+	seq      uint64
+	prio     int
+	anchored bool
 }
 type router []*entry
 type handler struct {
-	h     Handler
-	wares *wares
-}
-type logger interface {
-	Println(v ...interface{})
+	h, c    Handler
+	r       *route
+	wares   *wares
+	conds   []condition
+	cors    *CORS
+	methods []string
+	timeout time.Duration
+	nohead  bool
+	wait    bool
 }
 type stringer interface {
 	String() string
@@ -68,7 +91,109 @@ func (r router) Swap(i, j int) {
 	r[i], r[j] = r[j], r[i]
 }
 func (r router) Less(i, j int) bool {
-	return len(r[i].matcher.String()) < len(r[j].matcher.String())
+	if r[i].prio != r[j].prio {
+		return r[i].prio > r[j].prio
+	}
+	if a, b := r[i].specificity(), r[j].specificity(); a != b {
+		return a > b
+	}
+	if (r[i].host == nil) != (r[j].host == nil) {
+		return r[i].host != nil
+	}
+	return r[i].seq < r[j].seq
+}
+func (e *entry) methods() map[string]struct{} {
+	var (
+		r = make(map[string]struct{}, len(e.method)+1)
+		g bool
+	)
+	for n, h := range e.method {
+		if r[n] = struct{}{}; n == http.MethodGet && !h.nohead {
+			g = true
+		}
+	}
+	for i := range e.match {
+		for _, n := range e.match[i].methods {
+			if r[n] = struct{}{}; n == http.MethodGet && !e.match[i].nohead {
+				g = true
+			}
+		}
+	}
+	if g {
+		r[http.MethodHead] = struct{}{}
+	}
+	return r
+}
+
+// taken returns true if this entry has a Handler without match conditions for
+// any of the supplied methods, or a base Handler if no methods are supplied.
+func (e *entry) taken(methods []string) bool {
+	if len(methods) == 0 {
+		return e.base != nil
+	}
+	for _, n := range methods {
+		if _, ok := e.method[n]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allow returns the sorted list of methods that are allowed for this entry,
+// including OPTIONS. Entries with a base Handler allow all the standard methods.
+func (e *entry) allow() []string {
+	v := e.methods()
+	if v[http.MethodOptions] = struct{}{}; e.base != nil {
+		for _, n := range standard {
+			v[n] = struct{}{}
+		}
+	}
+	r := make([]string, 0, len(v))
+	for n := range v {
+		r = append(r, n)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// pick returns the Handler that will handle the supplied method for the request.
+// If no Handler is found, the status of the first failed match condition and
+// whether any conditional Handler was tried is returned instead.
+func (e *entry) pick(r *http.Request, s string) (*handler, int, bool) {
+	var (
+		c int
+		u bool
+	)
+	for _, v := range e.match {
+		if v.wait || !v.handles(s) {
+			continue
+		}
+		if x := v.allows(r); x > 0 {
+			if u = true; c == 0 {
+				c = x
+			}
+			continue
+		}
+		return v, 0, true
+	}
+	return e.method[s], c, u
+}
+func (e *entry) route() string {
+	switch {
+	case len(e.pattern) > 0:
+		return e.pattern
+	case e.matcher != nil:
+		return e.matcher.String()
+	case e.host != nil:
+		return e.host.String()
+	}
+	return ""
+}
+func (e *entry) specificity() int {
+	if !e.anchored {
+		return -1
+	}
+	return len(e.prefix)
 }
 
 // ServeHTTP allows RegexMux to fulfill the http.Handler interface.
@@ -81,163 +206,314 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r.Body.Close()
 		return
 	}
-	if p := clean(r.URL.Path); p != r.URL.Path {
-		u := *r.URL
-		if u.Path = p; m.log != nil {
-			m.log.Println(`[RouteX] Requested "` + r.URL.String() + `" redirecting to "` + u.String() + `".`)
+	var (
+		v      = &writer{ResponseWriter: w, t: time.Now()}
+		ctx, f = m.merge(r.Context())
+		id     string
+	)
+	defer f()
+	if m.ID != nil {
+		id = m.ID.id(r)
+		v.Header().Set(m.ID.header(), id)
+		ctx = context.WithValue(ctx, keyID, id)
+	}
+	if s := m.Path.path(r.URL); m.Path.Clean != CleanNone {
+		if p := m.Path.normalize(s); p != s {
+			u := *r.URL
+			if m.Path.set(&u, p); m.Path.Clean == CleanRewrite {
+				if m.verbose(ctx) {
+					m.log.DebugContext(ctx, "rewriting request path", slog.String("url", r.URL.String()), slog.String("target", u.String()))
+				}
+				q := new(http.Request)
+				*q = *r
+				q.URL, r = &u, q
+			} else {
+				if m.verbose(ctx) {
+					m.log.DebugContext(ctx, "redirecting request path", slog.String("url", r.URL.String()), slog.String("target", u.String()))
+				}
+				http.Redirect(v, r, u.String(), m.Path.status())
+				r.Body.Close()
+				m.Access.write(v, r, "", id)
+				return
+			}
+		}
+	}
+	x := m.serve(ctx, v, r, nil)
+	r.Body.Close()
+	m.Access.write(v, r, x.route, id)
+}
+func (m *Mux) serve(ctx context.Context, w http.ResponseWriter, r *http.Request, p values) *Request {
+	v, ok := w.(*writer)
+	if !ok {
+		v = &writer{ResponseWriter: w, t: time.Now()}
+	}
+	h, x, c := m.handler(ctx, m.Path.match(r.URL), r, p)
+	x.ctx, x.id = ctx, RequestID(ctx)
+	if c == http.StatusNoContent {
+		if w.Header().Set("Allow", strings.Join(x.allow, ", ")); x.cors != nil {
+			x.cors.preflight(w, r, x.allow)
 		}
-		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
-		r.Body.Close()
-		return
+		v.WriteHeader(http.StatusNoContent)
+		return x
 	}
-	ctx := m.ctx
-	if ctx == nil || ctx == context.Background() {
-		ctx = r.Context()
+	switch {
+	case h != nil && h.cors != nil:
+		h.cors.apply(w, r)
+	case m.CORS != nil:
+		m.CORS.apply(w, r)
 	}
-	h, x, a, f := m.handler(r.URL.Path, r)
-	if x == nil && f && len(a) > 0 {
-		w.Header().Set("Allow", a)
-		w.WriteHeader(http.StatusNoContent)
-		r.Body.Close()
-		return
+	if h != nil && x.head && !v.head {
+		v.head = true
+		m.process(ctx, h.c, h.wares, h.timeout, v, x)
+		v.finish()
+		return x
 	}
 	if h != nil {
-		m.process(ctx, h.h, h.wares, w, x)
-		x.Body.Close()
-		return
+		m.process(ctx, h.c, h.wares, h.timeout, v, x)
+		return x
 	}
-	if f {
-		m.handleError(http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed), w, x)
-		x.Body.Close()
-		return
+	if c == http.StatusMethodNotAllowed && len(x.allow) > 0 {
+		w.Header().Set("Allow", strings.Join(x.allow, ", "))
+	}
+	if c > 0 {
+		m.handleError(NewError(c, "", nil), v, x)
+		return x
 	}
 	if m.Default != nil {
-		m.process(ctx, m.Default, nil, w, x)
-		x.Body.Close()
-		return
+		m.process(ctx, m.Default, nil, 0, v, x)
+		return x
 	}
-	m.handleError(http.StatusNotFound, http.StatusText(http.StatusNotFound), w, x)
-	x.Body.Close()
+	m.handleError(NewError(http.StatusNotFound, "", nil), v, x)
+	return x
 }
-func (m *Mux) handleError(c int, s string, w http.ResponseWriter, r *Request) {
+
+// verbose returns true if the Mux logs at the debug level, so the attributes of
+// debug messages are only built when they will be written.
+func (m *Mux) verbose(x context.Context) bool {
+	return m.log != nil && m.log.Enabled(x, slog.LevelDebug)
+}
+func (m *Mux) handleError(e *Error, w http.ResponseWriter, r *Request) {
+	if v, ok := w.(ResponseWriter); ok && v.Written() {
+		if m.log != nil {
+			m.log.WarnContext(
+				r.Context(), "response already started, skipping error", slog.String("url", r.URL.String()),
+				slog.Int("status", e.Status), slog.String("error", e.Error()),
+			)
+		}
+		return
+	}
+	if e.Status >= http.StatusInternalServerError && m.log != nil {
+		m.log.WarnContext(
+			r.Context(), "server error returned", slog.String("url", r.URL.String()), slog.String("method", r.Method),
+			slog.String("route", r.route), slog.Int("status", e.Status), slog.String("error", e.Error()),
+		)
+	}
 	switch {
-	case c == http.StatusNotFound && m.Error404 != nil:
-		m.Error404.HandleError(c, s, w, r)
-	case c == http.StatusMethodNotAllowed && m.Error405 != nil:
-		m.Error405.HandleError(c, s, w, r)
-	case c == http.StatusInternalServerError && m.Error500 != nil:
-		m.Error500.HandleError(c, s, w, r)
+	case e.Status == http.StatusNotFound && m.Error404 != nil:
+		m.Error404.RespondError(e, w, r)
+	case e.Status == http.StatusMethodNotAllowed && m.Error405 != nil:
+		m.Error405.RespondError(e, w, r)
+	case e.Status == http.StatusInternalServerError && m.Error500 != nil:
+		m.Error500.RespondError(e, w, r)
 	case m.Error != nil:
-		m.Error.HandleError(c, s, w, r)
+		m.Error.RespondError(e, w, r)
 	default:
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.WriteHeader(c)
+		w.WriteHeader(e.Status)
 	}
 }
-func (m *Mux) handler(s string, r *http.Request) (*handler, *Request, string, bool) {
-	var h *handler
-	if m.lock.RLock(); m.log != nil {
-		m.log.Println(`[RouteX] URL "` + s + `" requested..`)
+func hostname(s string) string {
+	if i := strings.LastIndexByte(s, ':'); i > strings.LastIndexByte(s, ']') {
+		s = s[:i]
 	}
-	for i := range m.routes {
-		l := m.routes[i].matcher.FindStringSubmatch(s)
-		if len(l) == 0 {
+	return strings.ToLower(s)
+}
+func (m *Mux) capture(ctx context.Context, v values, x *regexp.Regexp, l []string, r *http.Request) {
+	if len(l) == 0 {
+		return
+	}
+	for z, n := range x.SubexpNames() {
+		if z == 0 || len(n) == 0 {
 			continue
 		}
-		if m.log != nil {
-			m.log.Println(`[RouteX] URL "` + s + `" was matched by "` + m.routes[i].matcher.String() + `".`)
+		if v[n] = value(l[z]); m.verbose(ctx) {
+			m.log.DebugContext(ctx, "captured value", slog.String("url", r.URL.String()), slog.String("name", n), slog.String("value", l[z]))
 		}
-		if len(m.routes[i].method) > 0 {
-			h = m.routes[i].method[r.Method]
+	}
+}
+func (m *Mux) request(r *http.Request, p values, n int) *Request {
+	x := &Request{Mux: m, Values: make(values, n+len(p)), Request: r}
+	for k, v := range p {
+		x.Values[k] = v
+	}
+	return x
+}
+func (h *handler) handles(s string) bool {
+	if len(h.methods) == 0 {
+		return true
+	}
+	for i := range h.methods {
+		if h.methods[i] == s {
+			return true
 		}
-		if h == nil {
-			if r.Method == http.MethodOptions {
-				if m.lock.RUnlock(); len(m.routes[i].method) > 0 {
-					var (
-						v strings.Builder
-						c uint
-					)
-					for n := range m.routes[i].method {
-						if c > 0 {
-							v.WriteString(", ")
-						}
-						v.WriteString(n)
-						c++
-					}
-					return nil, nil, v.String(), true
-				}
-				return nil, nil, "*", true
+	}
+	return false
+}
+func (m *Mux) handler(ctx context.Context, s string, r *http.Request, p values) (*handler, *Request, int) {
+	var (
+		t = m.load()
+		f = s
+		h *handler
+		b [16]int
+		n string
+		q []string
+		o int
+		k bool
+		g bool
+	)
+	if m.verbose(ctx) {
+		m.log.DebugContext(ctx, "path requested", slog.String("path", s), slog.String("method", r.Method))
+	}
+	if m.Path.CaseInsensitive {
+		f = lower(s)
+	}
+	for _, i := range t.index.lookup(f, b[:0]) {
+		if q = nil; t.routes[i].host != nil {
+			if !k {
+				n, k = hostname(r.Host), true
 			}
-			if h = m.routes[i].base; h == nil {
-				if m.lock.RUnlock(); m.log != nil {
-					m.log.Println(`[RouteX] URL "` + s + `" was matched, but method ` + r.Method + ` was not (default == nil) returning 405!`)
-				}
-				return nil, &Request{ctx: m.ctx, Mux: m, Request: r}, "", true
+			if q = t.routes[i].host.FindStringSubmatch(n); len(q) == 0 {
+				continue
 			}
 		}
-		x := &Request{ctx: m.ctx, Mux: m, Values: make(values, len(l)), Request: r}
-		for z, n := range m.routes[i].matcher.SubexpNames() {
-			if z == 0 || len(n) == 0 {
-				continue
+		l := m.Path.submatch(t.routes[i].matcher, s, f)
+		if len(l) == 0 {
+			continue
+		}
+		if m.verbose(ctx) {
+			m.log.DebugContext(ctx, "path matched", slog.String("path", s), slog.String("route", t.routes[i].matcher.String()))
+		}
+		if preflight(r) {
+			if v := t.routes[i].policy(r.Header.Get("Access-Control-Request-Method"), m.CORS); v != nil {
+				x := m.request(r, p, 0)
+				x.allow, x.cors, x.route = t.routes[i].allow(), v, t.routes[i].route()
+				return nil, x, http.StatusNoContent
+			}
+		}
+		h, c, u := t.routes[i].pick(r, r.Method)
+		if h == nil && r.Method == http.MethodHead {
+			v, x, z := t.routes[i].pick(r, http.MethodGet)
+			if v != nil && !v.nohead {
+				h, g = v, true
 			}
-			if x.Values[n] = value(l[z]); m.log != nil {
-				m.log.Println(`[RouteX] URL "` + r.URL.String() + `" "` + n + `=` + l[z] + `"`)
+			if u = u || z; c == 0 {
+				c = x
 			}
 		}
-		m.lock.RUnlock()
-		return h, x, "", true
-	}
-	m.lock.RUnlock()
-	return nil, &Request{ctx: m.ctx, Mux: m, Request: r}, "", false
-}
-func (m *Mux) process(ctx context.Context, h Handler, v *wares, w http.ResponseWriter, r *Request) {
-	defer func() {
-		if err := recover(); err != nil {
-			v := "unknown panic"
-			switch i := err.(type) {
-			case error:
-				v = i.Error()
-			case string:
-				v = i
-			case stringer:
-				v = i.String()
+		if c > 0 && o == 0 {
+			o = c
+		}
+		if h == nil {
+			if r.Method == http.MethodOptions {
+				x := m.request(r, p, 0)
+				x.allow, x.route = t.routes[i].allow(), t.routes[i].route()
+				return nil, x, http.StatusNoContent
 			}
-			if m.log != nil {
-				m.log.Println(`[RouteX] Request "` + r.URL.String() + `" recovered from a panic caused by "` + v + `"!`)
+			if h = t.routes[i].base; h == nil {
+				if u {
+					continue
+				}
+				if m.verbose(ctx) {
+					m.log.DebugContext(ctx,
+						"path matched, but method was not", slog.String("path", s),
+						slog.String("route", t.routes[i].matcher.String()), slog.String("method", r.Method),
+					)
+				}
+				x := m.request(r, p, len(l)+len(q))
+				m.capture(ctx, x.Values, t.routes[i].host, q, r)
+				m.capture(ctx, x.Values, t.routes[i].matcher, l, r)
+				x.allow, x.route = t.routes[i].allow(), t.routes[i].route()
+				return nil, x, http.StatusMethodNotAllowed
 			}
-			m.handleError(http.StatusInternalServerError, v, w, r)
 		}
-	}()
-	var (
-		x = ctx
-		f = func() {}
-	)
-	if m.Timeout > 0 {
-		x, f = context.WithTimeout(x, m.Timeout)
+		x := m.request(r, p, len(l)+len(q))
+		m.capture(ctx, x.Values, t.routes[i].host, q, r)
+		m.capture(ctx, x.Values, t.routes[i].matcher, l, r)
+		x.head, x.route = g, t.routes[i].route()
+		return h, x, http.StatusOK
+	}
+	if o > 0 && o != http.StatusNotFound {
+		if m.verbose(ctx) {
+			m.log.DebugContext(ctx, "path matched, but failed route conditions", slog.String("path", s), slog.Int("status", o))
+		}
+		return nil, &Request{Mux: m, Values: p, Request: r}, o
 	}
-	if m.wares != nil && len(m.wares.w) > 0 {
+	for i := range t.hosts {
+		if !k {
+			n, k = hostname(r.Host), true
+		}
+		if q = t.hosts[i].host.FindStringSubmatch(n); len(q) == 0 {
+			continue
+		}
+		if m.verbose(ctx) {
+			m.log.DebugContext(ctx, "host matched by default", slog.String("host", n), slog.String("route", t.hosts[i].host.String()))
+		}
+		x := m.request(r, p, len(q))
+		m.capture(ctx, x.Values, t.hosts[i].host, q, r)
+		h, x.route = t.hosts[i].base, t.hosts[i].route()
+		return h, x, http.StatusOK
+	}
+	return nil, &Request{Mux: m, Values: p, Request: r}, 0
+}
+func (m *Mux) process(ctx context.Context, h Handler, v *wares, d time.Duration, w http.ResponseWriter, r *Request) {
+	defer m.recover(ctx, w, r)
+	if d <= 0 {
+		d = m.Timeout
+	}
+	x := ctx
+	if d > 0 {
+		var f context.CancelFunc
+		x, f = context.WithTimeoutCause(x, d, errTimeout)
+		defer f()
+	}
+	if r.ctx = x; r.Request.Context() != x {
+		r.Request = r.Request.WithContext(x)
+	}
+	if d > 0 && m.Enforce {
+		m.enforce(x, h, v, d, w, r)
+		return
+	}
+	t := time.Now()
+	m.chain(x, h, v, w, r)
+	if d > 0 {
+		m.slow(x, r, d, time.Since(t))
+	}
+}
+func (m *Mux) chain(x context.Context, h Handler, v *wares, w http.ResponseWriter, r *Request) {
+	var c Handler
+	if m.wares != nil {
 		m.wares.lock.RLock()
 		for i := range m.wares.w {
 			if !m.wares.w[i](x, w, r) {
 				m.wares.lock.RUnlock()
-				f()
 				return
 			}
 		}
+		c = m.wares.h
 		m.wares.lock.RUnlock()
 	}
-	if v != nil && len(v.w) > 0 {
-		v.lock.RLock()
+	if v != nil {
 		for i := range v.w {
 			if !v.w[i](x, w, r) {
-				v.lock.RUnlock()
-				f()
 				return
 			}
 		}
-		v.lock.RUnlock()
 	}
-	h.Handle(x, w, r)
-	f()
+	if c == nil {
+		h.Handle(x, w, r)
+		return
+	}
+	r.next = h
+	c.Handle(x, w, r)
 }
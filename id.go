@@ -0,0 +1,166 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// keyID is the Context key used to store the request ID.
+const keyID = key(0)
+
+// maxID is the maximum length of an incoming request ID that will be accepted.
+const maxID = 128
+
+// crockford is the alphabet used to encode generated IDs. It sorts in the same
+// order as the values it encodes.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+type key uint8
+
+// IDPolicy is a struct that can be set as the 'ID' field of a Mux to assign an
+// ID to every request. The ID is echoed in the response Headers, added to every
+// log message written by the Mux and can be retrieved with the Request 'ID'
+// function or the 'RequestID' function using the Handler Context.
+//
+// Header is the name of the Header that is read and echoed and defaults to
+// 'X-Request-ID' when empty. If the Header is missing or invalid, the trace ID of
+// a 'traceparent' Header is used instead. If both are missing, an ID is created
+// using the Generate function, which defaults to 'NewID' when nil.
+//
+// Ignore can be set to always generate an ID, which is useful when clients are
+// not trusted to supply their own.
+type IDPolicy struct {
+	Generate func() string
+	Header   string
+	Ignore   bool
+}
+
+// contextual is a slog.Handler that adds the request ID stored in the log Context
+// to each record.
+type contextual struct {
+	slog.Handler
+}
+
+// NewID returns a new unique request ID. IDs are 26 characters long and start with
+// the current time in milliseconds, so IDs sort by the time they were created.
+func NewID() string {
+	var b [16]byte
+	rand.Read(b[6:])
+	t := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i], t = byte(t), t>>8
+	}
+	// 128 bits do not fit evenly in 26 characters, so the first one only holds the top
+	// 3 bits.
+	var (
+		o [26]byte
+		v uint
+		n = uint(2)
+		j int
+	)
+	for i := range b {
+		v, n = v<<8|uint(b[i]), n+8
+		for ; n >= 5; j++ {
+			n -= 5
+			o[j] = crockford[(v>>n)&31]
+		}
+	}
+	return string(o[:])
+}
+
+// RequestID returns the request ID stored in the supplied Context. This will
+// return an empty string if the Mux does not have an IDPolicy.
+func RequestID(x context.Context) string {
+	if x == nil {
+		return ""
+	}
+	if v, ok := x.Value(keyID).(string); ok {
+		return v
+	}
+	return ""
+}
+func valid(s string) bool {
+	if len(s) == 0 || len(s) > maxID {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '+', c == '/', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+func trace(s string) string {
+	// Format is "version-traceid-parentid-flags", where the trace ID is 32 hex
+	// characters and cannot be all zeros.
+	l := strings.Split(s, "-")
+	if len(l) < 4 || len(l[1]) != 32 || strings.Trim(l[1], "0") == "" {
+		return ""
+	}
+	for i := 0; i < len(l[1]); i++ {
+		if c := l[1][i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return ""
+		}
+	}
+	return l[1]
+}
+func (p *IDPolicy) header() string {
+	if len(p.Header) > 0 {
+		return p.Header
+	}
+	return "X-Request-ID"
+}
+func (p *IDPolicy) id(r *http.Request) string {
+	if !p.Ignore {
+		if v := r.Header.Get(p.header()); valid(v) {
+			return v
+		}
+		if v := trace(r.Header.Get("traceparent")); len(v) > 0 {
+			return v
+		}
+	}
+	if p.Generate != nil {
+		return p.Generate()
+	}
+	return NewID()
+}
+
+// ID returns the request ID assigned to this Request by the Mux IDPolicy. This
+// will return an empty string if the Mux does not have an IDPolicy.
+func (r *Request) ID() string {
+	return r.id
+}
+func (c contextual) WithGroup(n string) slog.Handler {
+	return contextual{c.Handler.WithGroup(n)}
+}
+func (c contextual) WithAttrs(a []slog.Attr) slog.Handler {
+	return contextual{c.Handler.WithAttrs(a)}
+}
+func (c contextual) Handle(x context.Context, r slog.Record) error {
+	if v := RequestID(x); len(v) > 0 {
+		r.AddAttrs(slog.String("id", v))
+	}
+	return c.Handler.Handle(x, r)
+}
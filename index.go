@@ -0,0 +1,109 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// index is a prefix tree built from the literal prefixes of the start-anchored
+// route matchers. Routes that are not anchored are stored in 'loose' and are
+// always considered candidates.
+//
+// Each node stores the positions (in the sorted router) of the routes whose
+// prefix ends at that node, so a lookup walks the requested path once and only
+// collects the routes that could possibly match.
+type index struct {
+	root  node
+	loose []int
+}
+type node struct {
+	next map[byte]*node
+	idx  []int
+}
+
+func (r router) index() *index {
+	var x index
+	for i := range r {
+		if !r[i].anchored {
+			x.loose = append(x.loose, i)
+			continue
+		}
+		n, p := &x.root, r[i].prefix
+		for z := 0; z < len(p); z++ {
+			if n.next == nil {
+				n.next = make(map[byte]*node, 1)
+			}
+			v, ok := n.next[p[z]]
+			if !ok {
+				v = new(node)
+				n.next[p[z]] = v
+			}
+			n = v
+		}
+		n.idx = append(n.idx, i)
+	}
+	return &x
+}
+
+// lookup returns the positions of the candidate routes for the supplied path in
+// evaluation order. The supplied slice is used as the backing buffer to prevent
+// allocations on small route sets.
+func (x *index) lookup(s string, b []int) []int {
+	if x == nil {
+		return b
+	}
+	b = append(b, x.loose...)
+	n := &x.root
+	for i := 0; ; i++ {
+		b = append(b, n.idx...)
+		if i >= len(s) || n.next == nil {
+			break
+		}
+		if n = n.next[s[i]]; n == nil {
+			break
+		}
+	}
+	if len(b) > 1 {
+		sort.Ints(b)
+	}
+	return b
+}
+
+// literalPrefix returns the literal string that every match of the supplied Regexp
+// must start with. The boolean is false if the expression is not anchored to the
+// start of the text, as the prefix could then appear anywhere.
+func literalPrefix(x *regexp.Regexp) (string, bool) {
+	r, err := syntax.Parse(x.String(), syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	r = r.Simplify()
+	if r.Op != syntax.OpConcat || len(r.Sub) == 0 || r.Sub[0].Op != syntax.OpBeginText {
+		return "", r.Op == syntax.OpBeginText
+	}
+	var b strings.Builder
+	for _, v := range r.Sub[1:] {
+		if v.Op != syntax.OpLiteral || v.Flags&syntax.FoldCase != 0 {
+			break
+		}
+		b.WriteString(string(v.Rune))
+	}
+	return b.String(), true
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"strconv"
+	"testing"
+)
+
+func first(t *table, s string) int {
+	var b [16]int
+	for _, i := range t.index.lookup(s, b[:0]) {
+		if t.routes[i].matcher.MatchString(s) {
+			return i
+		}
+	}
+	return -1
+}
+func linear(t *table, s string) int {
+	for i := range t.routes {
+		if t.routes[i].matcher.MatchString(s) {
+			return i
+		}
+	}
+	return -1
+}
+func TestIndexOrder(t *testing.T) {
+	m := New()
+	for _, v := range []string{
+		"^/api/v1/users/(?P<id>[0-9]+)$",
+		"^/api/v1/users$",
+		"^/api/",
+		"/users/",
+		"users$",
+		"(?i)^/API/v2/items$",
+		"(?i)/Files/",
+		"(?m)^/multi$",
+		"(?m)/line$",
+		"^/api/v1/(?i:Mixed)$",
+		"^/$",
+		"^/static/.*",
+	} {
+		m.Must(v, Func(nil))
+	}
+	x := m.load()
+	for _, s := range []string{
+		"/", "/api/v1/users", "/api/v1/users/10", "/api/v1/users/x", "/api/other", "/other/users/",
+		"/list/users", "/API/V2/ITEMS", "/api/v2/items", "/docs/files/a", "/docs/FILES/a", "/multi",
+		"/a\n/multi", "/a/line", "/a/line\n/b", "/api/v1/MIXED", "/api/v1/mixed", "/static/a.css", "/nope",
+	} {
+		if a, b := first(x, s), linear(x, s); a != b {
+			t.Fatalf("path %q: index matched %d, linear scan matched %d", s, a, b)
+		}
+	}
+}
+func BenchmarkIndexLookup(b *testing.B) {
+	m := New()
+	for i := 0; i < 400; i++ {
+		m.Must("^/api/v1/res"+strconv.Itoa(i)+"/(?P<id>[0-9]+)$", Func(nil))
+	}
+	var (
+		t = m.load()
+		s = "/api/v1/res399/100"
+	)
+	b.Run("Index", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if first(t, s) == -1 {
+				b.Fatal("no match")
+			}
+		}
+	})
+	b.Run("Linear", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if linear(t, s) == -1 {
+				b.Fatal("no match")
+			}
+		}
+	})
+}
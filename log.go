@@ -0,0 +1,106 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// printer is a slog.Handler that writes records to a legacy logger set using
+// 'SetLog'. Every level is enabled to keep the previous behavior.
+type printer struct {
+	l logger
+	g string
+	a []slog.Attr
+}
+type logger interface {
+	Println(v ...interface{})
+}
+
+// SetLog will set the internal logger for the Mux instance. This can be used to
+// debug any errors during runtime.
+//
+// Every message is written using 'Println', regardless of level. Use 'SetLogger'
+// to filter messages by level.
+func (m *Mux) SetLog(l logger) {
+	if l == nil {
+		m.log = nil
+		return
+	}
+	m.log = slog.New(contextual{&printer{l: l}})
+}
+
+// SetLogger will set the internal structured logger for the Mux instance. A nil
+// Logger disables logging.
+//
+// Per-request routing messages are written at the Debug level, while recovered
+// panics are written at the Error level and responses with a server error status
+// are written at the Warn level. Messages about a request include the request ID
+// when the Mux has an IDPolicy.
+func (m *Mux) SetLogger(l *slog.Logger) {
+	if l == nil {
+		m.log = nil
+		return
+	}
+	m.log = slog.New(contextual{l.Handler()})
+}
+func (*printer) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+func (p *printer) WithGroup(n string) slog.Handler {
+	if len(n) == 0 {
+		return p
+	}
+	return &printer{l: p.l, g: p.g + n + ".", a: p.a}
+}
+func (p *printer) WithAttrs(a []slog.Attr) slog.Handler {
+	n := make([]slog.Attr, 0, len(p.a)+len(a))
+	n = append(n, p.a...)
+	for i := range a {
+		n = append(n, slog.Attr{Key: p.g + a[i].Key, Value: a[i].Value})
+	}
+	return &printer{l: p.l, g: p.g, a: n}
+}
+func (p *printer) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString("[RouteX] " + r.Level.String() + " " + r.Message)
+	for i := range p.a {
+		attr(&b, "", p.a[i])
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		attr(&b, p.g, a)
+		return true
+	})
+	p.l.Println(b.String())
+	return nil
+}
+func attr(b *strings.Builder, g string, a slog.Attr) {
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if v := a.Value.Resolve(); v.Kind() == slog.KindGroup {
+		if len(a.Key) > 0 {
+			g += a.Key + "."
+		}
+		for _, x := range v.Group() {
+			attr(b, g, x)
+		}
+		return
+	}
+	b.WriteString(" " + g + a.Key + "=" + quote(a.Value.Resolve().String()))
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type condition struct {
+	f func(*http.Request) bool
+	c int
+}
+
+func mediaType(s string) string {
+	if t, _, err := mime.ParseMediaType(s); err == nil {
+		return t
+	}
+	if i := strings.IndexByte(s, ';'); i >= 0 {
+		s = s[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(s))
+}
+func mediaMatch(p, t string) bool {
+	if p == "*/*" || p == t {
+		return true
+	}
+	if strings.HasSuffix(p, "/*") {
+		return strings.HasPrefix(t, p[:len(p)-1])
+	}
+	if strings.HasSuffix(t, "/*") {
+		return strings.HasPrefix(p, t[:len(t)-1])
+	}
+	return false
+}
+func accepts(s string, t []string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, v := range strings.Split(s, ",") {
+		n, p, err := mime.ParseMediaType(v)
+		if err != nil {
+			n = mediaType(v)
+		}
+		if q, ok := p["q"]; ok {
+			if f, err := strconv.ParseFloat(q, 64); err == nil && f <= 0 {
+				continue
+			}
+		}
+		for i := range t {
+			if mediaMatch(n, t[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+func (h *handler) allows(r *http.Request) int {
+	for i := range h.conds {
+		if !h.conds[i].f(r) {
+			return h.conds[i].c
+		}
+	}
+	return 0
+}
+func (r *route) condition(f func(*http.Request) bool, c int) Route {
+	return r.change(func(e *entry, h *handler) {
+		if h.conds = append(append([]condition(nil), h.conds...), condition{f: f, c: c}); h.wait || len(h.conds) > 1 {
+			h.wait = false
+			return
+		}
+		if e.base == h {
+			e.base = nil
+		}
+		for k, v := range e.method {
+			if v == h {
+				delete(e.method, k)
+			}
+		}
+		e.match = append(e.match, h)
+	})
+}
+func (r *route) Header(k, v string) Route {
+	return r.condition(func(q *http.Request) bool {
+		l, ok := q.Header[http.CanonicalHeaderKey(k)]
+		if !ok || len(v) == 0 {
+			return ok
+		}
+		for i := range l {
+			if l[i] == v {
+				return true
+			}
+		}
+		return false
+	}, http.StatusNotFound)
+}
+func (r *route) Query(k, v string) Route {
+	return r.condition(func(q *http.Request) bool {
+		l, ok := q.URL.Query()[k]
+		if !ok || len(v) == 0 {
+			return ok
+		}
+		for i := range l {
+			if l[i] == v {
+				return true
+			}
+		}
+		return false
+	}, http.StatusNotFound)
+}
+func (r *route) Accept(v ...string) Route {
+	t := make([]string, len(v))
+	for i := range v {
+		t[i] = mediaType(v[i])
+	}
+	return r.condition(func(q *http.Request) bool {
+		return accepts(q.Header.Get("Accept"), t)
+	}, http.StatusNotAcceptable)
+}
+func (r *route) ContentType(v ...string) Route {
+	t := make([]string, len(v))
+	for i := range v {
+		t[i] = mediaType(v[i])
+	}
+	return r.condition(func(q *http.Request) bool {
+		n := mediaType(q.Header.Get("Content-Type"))
+		for i := range t {
+			if mediaMatch(t[i], n) {
+				return true
+			}
+		}
+		return false
+	}, http.StatusUnsupportedMediaType)
+}
+func (r *route) MatchFunc(f func(*http.Request) bool) Route {
+	if f == nil {
+		return r
+	}
+	return r.condition(f, http.StatusNotFound)
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func status(c int) Handler {
+	return Func(func(_ context.Context, w http.ResponseWriter, _ *Request) {
+		w.WriteHeader(c)
+	})
+}
+func TestConditionOrder(t *testing.T) {
+	for _, f := range []bool{false, true} {
+		for _, l := range [][]string{nil, {http.MethodGet}} {
+			m := New()
+			if f {
+				m.Must("^/a$", status(http.StatusCreated), l...)
+			}
+			m.Must("^/a$", status(http.StatusAccepted), l...).Header("X-Test", "1")
+			if !f {
+				m.Must("^/a$", status(http.StatusCreated), l...)
+			}
+			if v := m.Check(); len(v) > 0 {
+				t.Fatalf("unexpected issues %v", v)
+			}
+			for h, c := range map[string]int{"": http.StatusCreated, "1": http.StatusAccepted} {
+				w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil)
+				if len(h) > 0 {
+					r.Header.Set("X-Test", h)
+				}
+				if m.ServeHTTP(w, r); w.Code != c {
+					t.Fatalf("first %t methods %v header %q: got status %d, expected %d", f, l, h, w.Code, c)
+				}
+			}
+		}
+	}
+}
+func TestConditionDuplicate(t *testing.T) {
+	m := New()
+	m.Must("^/a$", status(http.StatusCreated))
+	m.Must("^/a$", status(http.StatusAccepted))
+	w := httptest.NewRecorder()
+	if m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/a", nil)); w.Code != http.StatusCreated {
+		t.Fatalf("duplicate Handler replaced the existing Handler, got status %d", w.Code)
+	}
+	if v := m.Check(); len(v) != 1 || v[0].Kind != IssueDuplicate {
+		t.Fatalf("expected a duplicate Issue, got %v", v)
+	}
+}
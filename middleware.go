@@ -14,8 +14,23 @@ import (
 // back control to implement features such as redirects or authentication.
 type Middleware func(context.Context, http.ResponseWriter, *Request) bool
 
+// Chain is a function alias that can be used to wrap a Handler with another
+// Handler. Unlike Middleware, a Chain controls when (and if) the next Handler is
+// called, so it can run code after the Handler returns, replace the ResponseWriter
+// or recover from panics caused by the Handler.
+//
+// Chains are ran AFTER all the Mux and Route Middleware have passed. Mux Chains
+// wrap Route Chains and the first Chain added is the outermost one. For example
+// with Mux Chains 'A' and 'B' and a Route Chain 'C', the request would be handled
+// in the order: Mux Middleware, Route Middleware, A, B, C, Handler.
+//
+// Chains are called once when they are added to build the wrapped Handler, not
+// on every request.
+type Chain func(next Handler) Handler
+
 // Middleware adds the supplied Middleware functions to the Mux.
-// These are ran before control is passed until the Handler.
+// These are ran before control is passed until the Handler. Use the 'Chain'
+// function to add Middleware that wraps the Handler instead.
 //
 // And empty function is considered a NOP.
 func (m *Mux) Middleware(w ...Middleware) {
@@ -30,16 +45,62 @@ func (m *Mux) Middleware(w ...Middleware) {
 	m.wares.w = append(m.wares.w, w...)
 	m.wares.lock.Unlock()
 }
-func (h *handler) Middleware(w ...Middleware) Route {
+func (r *route) Middleware(w ...Middleware) Route {
 	if len(w) == 0 {
-		return h
+		return r
+	}
+	return r.change(func(_ *entry, h *handler) {
+		if h.wares == nil {
+			h.wares = &wares{w: w}
+			return
+		}
+		h.wares = &wares{w: append(append([]Middleware(nil), h.wares.w...), w...), c: h.wares.c}
+	})
+}
+
+// Chain adds the supplied Chain functions to the Mux. These wrap the Handler
+// after all the Middleware have passed.
+//
+// And empty function is considered a NOP.
+func (m *Mux) Chain(c ...Chain) {
+	if len(c) == 0 {
+		return
+	}
+	if m.wares == nil {
+		m.wares = &wares{c: c}
+		m.wares.h = m.wares.wrap(Func(next))
+		return
+	}
+	m.wares.lock.Lock()
+	m.wares.c = append(m.wares.c, c...)
+	m.wares.h = m.wares.wrap(Func(next))
+	m.wares.lock.Unlock()
+}
+func (r *route) Chain(c ...Chain) Route {
+	if len(c) == 0 {
+		return r
 	}
-	if h.wares == nil {
-		h.wares = &wares{w: w}
-		return h
+	return r.change(func(_ *entry, h *handler) {
+		if h.wares == nil {
+			h.wares = &wares{c: c}
+		} else {
+			h.wares = &wares{w: h.wares.w, c: append(append([]Chain(nil), h.wares.c...), c...)}
+		}
+		h.c = h.wares.wrap(h.h)
+	})
+}
+
+// next is the Handler wrapped by the Mux Chains, which calls the Handler that was
+// selected for the Request.
+func next(x context.Context, w http.ResponseWriter, r *Request) {
+	r.next.Handle(x, w, r)
+}
+
+// wrap returns the supplied Handler wrapped by the Chains. This is only called
+// when the Chains change, so requests use the already wrapped Handler.
+func (v *wares) wrap(h Handler) Handler {
+	for i := len(v.c) - 1; i >= 0; i-- {
+		h = v.c[i](h)
 	}
-	h.wares.lock.Lock()
-	h.wares.w = append(h.wares.w, w...)
-	h.wares.lock.Unlock()
 	return h
 }
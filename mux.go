@@ -17,10 +17,11 @@ package routex
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"regexp"
-	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -44,27 +45,119 @@ const (
 //
 // This Handler supports a base context that can be used to signal closure to all
 // running Handlers.
+//
+// When 'Strict' is true, the 'Add*' functions will return an Issue as an error
+// if the added route is not anchored, is shadowed by an existing route or shadows
+// an existing route. See the 'Check' function for more info.
+//
+// The 'Path' field controls how request paths are cleaned and matched. See the
+// PathPolicy struct for more info.
+//
+// The 'Access' field can be set to an AccessLog to write a record of every request
+// handled. This is separate from the logger set with 'SetLogger'.
+//
+// The 'Recover' field controls how panics caused by Handlers are handled and the
+// 'Panic' field can be set to a PanicHandler to be notified of them. By default
+// panics are recovered and a generic 500 error is returned.
+//
+// The 'ID' field can be set to an IDPolicy to assign an ID to every request.
+//
+// The 'Timeout' field sets the default timeout of the Handler Context, which can
+// be overridden using the Route 'Timeout' function. When 'Enforce' is true, the
+// Handler response is buffered and the 'TimeoutStatus' (which defaults to 503)
+// is returned using the ErrorResponders once the timeout passes, even if the
+// Handler does not return. The 'Slow' field can be set to a SlowHandler to be
+// notified of any Handler that returns after its timeout.
 type Mux struct {
 	lock sync.RWMutex
 
-	Error, Error404    ErrorHandler
-	Error405, Error500 ErrorHandler
+	Error, Error404    ErrorResponder
+	Error405, Error500 ErrorResponder
 	ctx                context.Context
-	log                logger
+	log                *slog.Logger
+	types              map[string]string
 
 	Default Handler
+	CORS    *CORS
+	Path    PathPolicy
+	Access  *AccessLog
+	Panic   PanicHandler
+	ID      *IDPolicy
+	Slow    SlowHandler
 	wares   *wares
-	routes  router
+	table   atomic.Value
+	seq     uint64
 
-	Timeout time.Duration
+	Timeout       time.Duration
+	TimeoutStatus int
+	Strict        bool
+	Enforce       bool
+	Recover       recovery
 }
 
 // Route is an interface that allows for modification of an added HTTP route after
 // being created.
 //
 // One example function is adding route-specific middleware.
+//
+// Match conditions allow for multiple Handlers on the same path and method,
+// where the first Route with passing conditions (in the order they were added)
+// is used before falling back to a Route without any conditions. If no other path
+// matches, the status of the first failed condition is returned.
+//
+// The order Routes are added in does not matter. A Route added to a path (and
+// methods) that already has a Handler without conditions does not replace it and
+// is not used until a condition is added to it, which is reported by the Mux
+// 'Check' function if it never is. Use the 'Replace' function to replace the
+// Handler instead.
 type Route interface {
+	// Name names the path the Route belongs to, which allows for generating
+	// concrete paths using the Mux 'URL' function. Setting a name already in use
+	// will replace the previous route with that name.
+	Name(n string) Route
+	// Priority sets the priority of the path the Route belongs to. Paths are
+	// evaluated by descending priority first, then by the length of their literal
+	// prefix (longest, and thus most specific, first) and lastly by the order
+	// they were registered in. All paths start with a priority of zero.
+	Priority(p int) Route
+	// Middleware adds the supplied Middleware functions to the Route. These are
+	// ran after any Mux Middleware.
 	Middleware(m ...Middleware) Route
+	// Chain adds the supplied Chain functions to the Route. These wrap the Route
+	// Handler inside any Mux Chains.
+	Chain(c ...Chain) Route
+	// Timeout sets the timeout of the Route Handler Context, which overrides the
+	// Mux 'Timeout' value. A timeout of zero will use the Mux 'Timeout' value.
+	Timeout(d time.Duration) Route
+	// NoHead prevents this Route from automatically handling HEAD requests when
+	// it handles the GET method. By default, HEAD requests on a path without a
+	// HEAD Handler are passed to the GET Handler with the response body discarded.
+	NoHead() Route
+	// CORS sets the CORS policy for this Route, which overrides the Mux CORS
+	// policy. A nil policy will use the Mux CORS policy.
+	CORS(c *CORS) Route
+
+	// Header adds a match condition that requires the request Header with the
+	// supplied name to contain the supplied value. An empty value only requires
+	// the Header to be present.
+	Header(k, v string) Route
+	// Query adds a match condition that requires the URL query value with the
+	// supplied name to contain the supplied value. An empty value only requires
+	// the value to be present.
+	Query(k, v string) Route
+	// Accept adds a match condition that requires the request 'Accept' Header
+	// to allow at least one of the supplied media types. Requests without an
+	// 'Accept' Header will always match. Requests that only fail this condition
+	// will return a 406 status.
+	Accept(t ...string) Route
+	// ContentType adds a match condition that requires the request 'Content-Type'
+	// Header to be one of the supplied media types, which may use a wildcard
+	// subtype such as "text/*". Requests that only fail this condition will
+	// return a 415 status.
+	ContentType(t ...string) Route
+	// MatchFunc adds a match condition that requires the supplied function to
+	// return true for the request.
+	MatchFunc(f func(*http.Request) bool) Route
 }
 
 // Handler is a fork of the http.Handler interface. This interface supplies a base
@@ -79,33 +172,43 @@ type Handler interface {
 //
 // The 'HandleError' method will be called with an error status code, error message
 // and the standard 'Handler' options (except the Context).
+//
+// This is the legacy error interface, use the 'AdaptError' function to use it
+// as an ErrorResponder.
 type ErrorHandler interface {
 	HandleError(int, string, http.ResponseWriter, *Request)
 }
 
+// ErrorResponder is an interface that allows for handling any error returns to
+// be reported to the client instead of using the default methods.
+//
+// The 'RespondError' method will be called with the error and the standard
+// 'Handler' options (except the Context). The error is always an '*Error', which
+// contains the status code, public message and the underlying cause.
+type ErrorResponder interface {
+	RespondError(error, http.ResponseWriter, *Request)
+}
+
 // New returns a new Mux instance.
 func New() *Mux {
 	return new(Mux)
 }
 
-// SetLog will set the internal logger for the Mux instance. This can be used to
-// debug any errors during runtime.
-func (m *Mux) SetLog(l logger) {
-	m.log = l
-}
-
 // NewContext creates a new Mux and applies the supplied Context as the Mux base Context.
+//
+// Handler Contexts are canceled when either the base Context or the client request
+// Context is done.
 func NewContext(x context.Context) *Mux {
 	return &Mux{ctx: x}
 }
 
-// Must adds the Handler to the supplied regex expression path. Path values must
-// be unique and don't have to contain regex expressions.
+// Must adds the Handler to the supplied regex expression path. Path values don't
+// have to contain regex expressions.
 //
 // Regex match groups can be used to grab data out of the call and will be placed
 // in the 'Values' Request map.
 //
-// This function panics if a duplicate path exists or the regex expression is invalid.
+// This function panics if the regex expression is invalid.
 //
 // This function will add a handler that will be considered the 'default' handler
 // for the path and will be called unless a method-based Handler is also specified
@@ -118,14 +221,13 @@ func (m *Mux) Must(path string, h Handler, methods ...string) Route {
 	return v
 }
 
-// Add adds the Handler to the supplied regex expression path. Path values must be
-// unique and don't have to contain regex expressions.
+// Add adds the Handler to the supplied regex expression path. Path values don't have to
+// contain regex expressions.
 //
 // Regex match groups can be used to grab data out of the call and will be placed
 // in the 'Values' Request map.
 //
-// This function returns an error if a duplicate path exists or the regex expression
-// is invalid.
+// This function returns an error if the regex expression is invalid.
 //
 // This function will add a handler that will be considered the 'default' handler
 // for the path and will be called unless a method-based Handler is also specified
@@ -141,16 +243,16 @@ func (m *Mux) Add(path string, h Handler, methods ...string) (Route, error) {
 	if err != nil {
 		return nil, &errValue{s: `path "` + path + `" compile`, e: err}
 	}
-	return m.add(path, methods, x, h)
+	return m.add(&entry{matcher: x}, methods, h, false)
 }
 
-// MustExp adds the Handler to the supplied regex expression. Path values must be
-// unique and don't have to contain regex expressions.
+// MustExp adds the Handler to the supplied regex expression. Path values don't have to
+// contain regex expressions.
 //
 // Regex match groups can be used to grab data out of the call and will be placed
 // in the 'Values' Request map.
 //
-// This function panics if a duplicate path exists or the regex expression is invalid.
+// This function panics if the regex expression is invalid.
 //
 // This function will add a handler that will be considered the 'default' handler
 // for the path and will be called unless a method-based Handler is also specified
@@ -163,14 +265,13 @@ func (m *Mux) MustExp(exp *regexp.Regexp, h Handler, methods ...string) Route {
 	return v
 }
 
-// AddExp adds the Handler to the supplied regex expression. Path values must be
-// unique and don't have to contain regex expressions.
+// AddExp adds the Handler to the supplied regex expression. Path values don't have to
+// contain regex expressions.
 //
 // Regex match groups can be used to grab data out of the call and will be placed
 // in the 'Values' Request map.
 //
-// This function returns an error if a duplicate path exists or the regex expression
-// is invalid.
+// This function returns an error if the regex expression is invalid.
 //
 // This function will add a handler that will be considered the 'default' handler
 // for the path and will be called unless a method-based Handler is also specified
@@ -186,54 +287,89 @@ func (m *Mux) AddExp(exp *regexp.Regexp, h Handler, methods ...string) (Route, e
 	if h == nil {
 		return nil, ErrInvalidHandler
 	}
-	return m.add(v, methods, exp, h)
+	return m.add(&entry{matcher: exp}, methods, h, false)
 }
-func (m *Mux) add(path string, methods []string, x *regexp.Regexp, h Handler) (*handler, error) {
+func (m *Mux) add(e *entry, methods []string, h Handler, replace bool) (*route, error) {
 	for _, n := range methods {
 		if len(n) == 0 {
 			return nil, ErrInvalidMethod
 		}
 	}
-	if m.lock.Lock(); len(m.routes) > 0 {
-		for i := range m.routes {
-			if m.routes[i].matcher.String() != path {
+	var (
+		v    = &handler{h: h, c: h, r: newRoute(m), methods: methods}
+		path = e.matcher.String()
+	)
+	m.lock.Lock()
+	err := m.update(func(t *table) error {
+		for i := range t.routes {
+			if t.routes[i].matcher.String() != path || !sameHost(t.routes[i].host, e.host) {
 				continue
 			}
-			if len(methods) > 0 {
-				if m.routes[i].method == nil {
-					m.routes[i].method = make(map[string]*handler, len(methods))
+			n := t.entry(t.routes[i].seq)
+			if len(n.params) == 0 && len(e.params) > 0 {
+				n.pattern, n.params = e.pattern, e.params
+			}
+			switch v.r.id = n.seq; {
+			case !replace && n.taken(methods):
+				// Wait in the match list until a condition is added, so the
+				// existing Handler keeps being used.
+				v.wait = true
+				n.match = append(n.match, v)
+			case len(methods) == 0:
+				n.base = v
+			default:
+				if n.method == nil {
+					n.method = make(map[string]*handler, len(methods))
 				}
-				v := &handler{h: h}
-				for _, n := range methods {
-					m.routes[i].method[n] = v
+				for _, k := range methods {
+					n.method[k] = v
 				}
-				m.lock.Unlock()
-				return v, nil
 			}
-			if m.routes[i].base != nil {
-				m.lock.Unlock()
-				return nil, errStr(`matcher path "` + path + `" already exists`)
+			if !m.Strict {
+				return nil
 			}
-			v := &handler{h: h}
-			m.routes[i].base = v
-			m.lock.Unlock()
-			return v, nil
+			t.build()
+			return t.strict(n.seq)
 		}
-	}
-	var (
-		v = &handler{h: h}
-		e = &entry{matcher: x}
-	)
-	if len(methods) > 0 {
-		e.method = make(map[string]*handler, len(methods))
-		for _, n := range methods {
-			e.method[n] = v
+		m.seq++
+		e.seq, v.r.id = m.seq, m.seq
+		e.prefix, e.anchored = literalPrefix(e.matcher)
+		if len(methods) > 0 {
+			e.method = make(map[string]*handler, len(methods))
+			for _, n := range methods {
+				e.method[n] = v
+			}
+		} else {
+			e.base = v
+		}
+		if t.routes = append(t.routes, e); !m.Strict {
+			return nil
 		}
-	} else {
-		e.base = v
+		t.build()
+		return t.strict(e.seq)
+	})
+	if m.lock.Unlock(); err != nil {
+		return nil, err
 	}
-	m.routes = append(m.routes, e)
-	sort.Sort(m.routes)
-	m.lock.Unlock()
-	return v, nil
+	return v.r, nil
+}
+
+// Order returns the path expressions of all the added routes in the order they
+// will be evaluated in when matching a request.
+func (m *Mux) Order() []string {
+	t := m.load()
+	r := make([]string, len(t.routes))
+	for i := range t.routes {
+		r[i] = t.routes[i].matcher.String()
+	}
+	return r
+}
+func (r *route) Priority(p int) Route {
+	r.update(func(t *table) error {
+		if e := t.entry(r.id); e != nil {
+			e.prio = p
+		}
+		return nil
+	})
+	return r
 }
@@ -0,0 +1,122 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime"
+)
+
+const (
+	// RecoverRespond is the default Recover policy. Panics are recovered and a
+	// generic 500 error is returned to the client using the Mux ErrorResponders.
+	RecoverRespond recovery = iota
+	// RecoverAbort is a Recover policy that recovers panics to log them and call
+	// the PanicHandler, then panics again with 'http.ErrAbortHandler' to abort the
+	// response without the http.Server logging the panic again.
+	RecoverAbort
+	// RecoverNone is a Recover policy that does not recover panics at all, which
+	// can be useful in tests.
+	RecoverNone
+)
+
+type recovery uint8
+
+// PanicHandler is an interface that can be set as the 'Panic' field of a Mux to
+// be notified of any recovered panics, such as for alerting.
+//
+// The 'HandlePanic' method will be called with the Handler Context, the Request,
+// the recovered value and the stack trace of the panic. It is called before the
+// response is written, unless an enforced Handler panics after its timeout response
+// was already sent.
+type PanicHandler interface {
+	HandlePanic(context.Context, *Request, any, []byte)
+}
+
+func (m *Mux) recover(x context.Context, w http.ResponseWriter, r *Request) {
+	if m.Recover == RecoverNone {
+		return
+	}
+	err := recover()
+	if err == nil {
+		return
+	}
+	var s []byte
+	if p, ok := err.(panicked); ok {
+		err, s = p.v, p.s
+	}
+	if err == http.ErrAbortHandler {
+		panic(err)
+	}
+	v := panicString(err)
+	if s == nil {
+		s = stack()
+	}
+	m.report(x, r, err, v, s)
+	if m.Recover == RecoverAbort {
+		panic(http.ErrAbortHandler)
+	}
+	m.handleError(NewError(http.StatusInternalServerError, "", errStr("panic: "+v)), w, r)
+}
+func (m *Mux) report(x context.Context, r *Request, err any, v string, s []byte) {
+	if m.log != nil {
+		m.log.ErrorContext(
+			x, "recovered from a panic", slog.String("url", r.URL.String()), slog.String("method", r.Method),
+			slog.String("route", r.route), slog.String("panic", v), slog.String("stack", string(s)),
+		)
+	}
+	if m.Panic != nil {
+		m.Panic.HandlePanic(x, r, err, s)
+	}
+}
+
+// late reports a panic from an enforced Handler that happened after the timeout
+// response was already sent, so it can only be logged and passed to the Mux
+// PanicHandler.
+func (m *Mux) late(x context.Context, r *Request, err any, s []byte) {
+	if err == http.ErrAbortHandler {
+		return
+	}
+	if m.Recover == RecoverNone {
+		panic(err)
+	}
+	m.report(x, r, err, panicString(err), s)
+}
+func panicString(err any) string {
+	v := "unknown panic"
+	switch i := err.(type) {
+	case error:
+		v = i.Error()
+	case string:
+		v = i
+	case stringer:
+		v = i.String()
+	}
+	return v
+}
+
+// stack returns the stack trace of the current goroutine, like 'debug.Stack'.
+func stack() []byte {
+	b := make([]byte, 1024)
+	for {
+		if n := runtime.Stack(b, false); n < len(b) {
+			return b[:n]
+		}
+		b = make([]byte, len(b)*2)
+	}
+}
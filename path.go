@@ -0,0 +1,172 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	// CleanRedirect is the default Clean action. Requests with a path that is not
+	// clean will be redirected to the cleaned path.
+	CleanRedirect action = iota
+	// CleanRewrite is a Clean action that will match and handle requests with a
+	// path that is not clean using the cleaned path without a redirect.
+	CleanRewrite
+	// CleanNone is a Clean action that will match requests using the path as-is
+	// without any cleaning.
+	CleanNone
+)
+const (
+	// SlashKeep is the default Slash action. Trailing slashes are kept as they
+	// were requested.
+	SlashKeep slash = iota
+	// SlashAdd is a Slash action that adds a trailing slash to any path that does
+	// not have one.
+	SlashAdd
+	// SlashRemove is a Slash action that removes the trailing slash from any path
+	// that has one.
+	SlashRemove
+	// SlashIgnore is a Slash action that ignores trailing slashes when matching,
+	// without changing the requested path. Route expressions should not expect
+	// a trailing slash when using this action.
+	SlashIgnore
+)
+
+type slash uint8
+type action uint8
+
+// PathPolicy is a struct that controls how the Mux cleans and matches request
+// paths. The zero value will redirect requests that are not clean with a 301
+// status and match the path case-sensitively, which is the default behavior.
+//
+// Clean controls what happens when the cleaned path (including any Slash changes)
+// is different from the requested path. Status can be set to 308 to prevent
+// clients from changing POST requests into GET requests when redirected and
+// defaults to 301 when zero.
+//
+// CaseInsensitive will match the path in lowercase, so route expressions should
+// be in lowercase. Captured Values will keep the requested case.
+//
+// RawPath will match on the escaped path, so that escaped characters (such as
+// '%2F') inside a path segment are kept. Captured Values are unescaped.
+type PathPolicy struct {
+	Clean           action
+	Slash           slash
+	Status          int
+	CaseInsensitive bool
+	RawPath         bool
+}
+
+func lower(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 'A' && s[i] <= 'Z' {
+			b := []byte(s)
+			for ; i < len(b); i++ {
+				if b[i] >= 'A' && b[i] <= 'Z' {
+					b[i] += 'a' - 'A'
+				}
+			}
+			return string(b)
+		}
+	}
+	return s
+}
+func (p *PathPolicy) status() int {
+	if p.Status > 0 {
+		return p.Status
+	}
+	return http.StatusMovedPermanently
+}
+func (p *PathPolicy) path(u *url.URL) string {
+	if p.RawPath {
+		return u.EscapedPath()
+	}
+	return u.Path
+}
+
+// match returns the string used to match routes from the supplied URL.
+func (p *PathPolicy) match(u *url.URL) string {
+	s := p.path(u)
+	if p.Slash == SlashIgnore && len(s) > 1 && s[len(s)-1] == '/' {
+		if s = strings.TrimRight(s, "/"); len(s) == 0 {
+			return "/"
+		}
+	}
+	return s
+}
+func (p *PathPolicy) normalize(s string) string {
+	if p.Clean == CleanNone {
+		return s
+	}
+	n := clean(s)
+	switch p.Slash {
+	case SlashAdd:
+		if n[len(n)-1] != '/' {
+			n += "/"
+		}
+	case SlashRemove:
+		if len(n) > 1 && n[len(n)-1] == '/' {
+			if n = strings.TrimRight(n, "/"); len(n) == 0 {
+				n = "/"
+			}
+		}
+	}
+	return n
+}
+
+// set updates the supplied URL to use the supplied path, which is escaped if
+// the RawPath option is enabled.
+func (p *PathPolicy) set(u *url.URL, s string) {
+	if !p.RawPath {
+		u.Path, u.RawPath = s, ""
+		return
+	}
+	v, err := url.PathUnescape(s)
+	if err != nil {
+		u.Path, u.RawPath = s, ""
+		return
+	}
+	u.Path, u.RawPath = v, s
+}
+
+// submatch acts like 'FindStringSubmatch' but matches using the lowercase path
+// when CaseInsensitive is enabled and unescapes the results when RawPath is
+// enabled.
+func (p *PathPolicy) submatch(x *regexp.Regexp, s, f string) []string {
+	if s == f && !p.RawPath {
+		return x.FindStringSubmatch(s)
+	}
+	i := x.FindStringSubmatchIndex(f)
+	if i == nil {
+		return nil
+	}
+	l := make([]string, len(i)/2)
+	for z := range l {
+		if i[z*2] < 0 {
+			continue
+		}
+		if l[z] = s[i[z*2]:i[z*2+1]]; p.RawPath && z > 0 {
+			if v, err := url.PathUnescape(l[z]); err == nil {
+				l[z] = v
+			}
+		}
+	}
+	return l
+}
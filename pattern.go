@@ -0,0 +1,202 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// ErrInvalidPattern is returned from the 'AddPattern' functions when the
+	// pattern contains an unterminated, empty or malformed parameter.
+	ErrInvalidPattern = errStr("supplied pattern is invalid")
+	// ErrInvalidTypeName is returned from the 'AddType' function when the type
+	// name is empty or contains invalid characters.
+	ErrInvalidTypeName = errStr("supplied type name is invalid")
+)
+
+// types is the set of built-in parameter types that can be used in patterns
+// without registering them first.
+//
+// The empty type is used when a parameter does not specify a type and will match
+// a single path segment.
+var types = map[string]string{
+	"":      `[^/]+`,
+	"*":     `.*`,
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"slug":  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+	"alpha": `[a-zA-Z]+`,
+}
+
+type param struct {
+	name, kind string
+}
+
+func validName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := range s {
+		switch c := s[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// AddType registers a named parameter type that can be used in patterns passed
+// to the 'AddPattern' functions. The expression is the regex used to match the
+// parameter value and must not contain any capture groups.
+//
+// Registered types take precedence over the built-in types (int, uint, uuid,
+// slug, alpha and '*').
+//
+// This function returns an error if the name is invalid or the expression fails
+// to compile.
+func (m *Mux) AddType(name, expr string) error {
+	if !validName(name) {
+		return ErrInvalidTypeName
+	}
+	if len(expr) == 0 {
+		return ErrInvalidPath
+	}
+	x, err := regexp.Compile(expr)
+	if err != nil {
+		return &errValue{s: `type "` + name + `" compile`, e: err}
+	}
+	if x.NumSubexp() > 0 {
+		return errStr(`type "` + name + `" expression cannot contain capture groups`)
+	}
+	m.lock.Lock()
+	if m.types == nil {
+		m.types = make(map[string]string, 1)
+	}
+	m.types[name] = expr
+	m.lock.Unlock()
+	return nil
+}
+
+// MustPattern adds the Handler to the supplied declarative pattern. Patterns are
+// literal paths that may contain typed parameters in the form of '{name}' or
+// '{name:type}', such as "/users/{id:int}/files/{path:*}".
+//
+// Parameter values will be placed in the 'Values' Request map under the parameter
+// name.
+//
+// This function panics if the pattern is invalid.
+//
+// This function will add a handler that will be considered the 'default' handler
+// for the path and will be called unless a method-based Handler is also specified
+// and that HTTP method is used.
+func (m *Mux) MustPattern(pattern string, h Handler, methods ...string) Route {
+	v, err := m.AddPattern(pattern, h, methods...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return v
+}
+
+// AddPattern adds the Handler to the supplied declarative pattern. Patterns are
+// literal paths that may contain typed parameters in the form of '{name}' or
+// '{name:type}', such as "/users/{id:int}/files/{path:*}".
+//
+// Parameters without a type will match a single path segment. Parameter values
+// will be placed in the 'Values' Request map under the parameter name.
+//
+// This function returns an error if the pattern is invalid or the pattern
+// references an unknown type.
+//
+// This function will add a handler that will be considered the 'default' handler
+// for the path and will be called unless a method-based Handler is also specified
+// and that HTTP method is used.
+func (m *Mux) AddPattern(pattern string, h Handler, methods ...string) (Route, error) {
+	if len(pattern) == 0 {
+		return nil, ErrInvalidPath
+	}
+	if h == nil {
+		return nil, ErrInvalidHandler
+	}
+	e, err := m.compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return m.add(e, methods, h, false)
+}
+func (m *Mux) compile(pattern string) (*entry, error) {
+	var (
+		b strings.Builder
+		p []param
+	)
+	b.WriteByte('^')
+	m.lock.RLock()
+	for s := pattern; len(s) > 0; {
+		i := strings.IndexByte(s, '{')
+		if i == -1 {
+			if strings.IndexByte(s, '}') >= 0 {
+				m.lock.RUnlock()
+				return nil, ErrInvalidPattern
+			}
+			b.WriteString(regexp.QuoteMeta(s))
+			break
+		}
+		if strings.IndexByte(s[:i], '}') >= 0 {
+			m.lock.RUnlock()
+			return nil, ErrInvalidPattern
+		}
+		b.WriteString(regexp.QuoteMeta(s[:i]))
+		n := strings.IndexByte(s[i:], '}')
+		if n == -1 {
+			m.lock.RUnlock()
+			return nil, ErrInvalidPattern
+		}
+		v := param{name: s[i+1 : i+n]}
+		if x := strings.IndexByte(v.name, ':'); x >= 0 {
+			v.name, v.kind = v.name[:x], v.name[x+1:]
+		}
+		if !validName(v.name) {
+			m.lock.RUnlock()
+			return nil, ErrInvalidPattern
+		}
+		for z := range p {
+			if p[z].name == v.name {
+				m.lock.RUnlock()
+				return nil, errStr(`pattern "` + pattern + `" parameter "` + v.name + `" is duplicated`)
+			}
+		}
+		x, ok := m.types[v.kind]
+		if !ok {
+			if x, ok = types[v.kind]; !ok {
+				m.lock.RUnlock()
+				return nil, errStr(`pattern "` + pattern + `" type "` + v.kind + `" is not known`)
+			}
+		}
+		b.WriteString("(?P<" + v.name + ">" + x + ")")
+		p = append(p, v)
+		s = s[i+n+1:]
+	}
+	m.lock.RUnlock()
+	b.WriteByte('$')
+	x, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, &errValue{s: `pattern "` + pattern + `" compile`, e: err}
+	}
+	return &entry{matcher: x, pattern: pattern, params: p}, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// problemTypes is the list of media types that the Problem ErrorResponder will
+// write JSON for.
+var problemTypes = []string{"application/problem+json", "application/json"}
+
+// Problem is an ErrorResponder that writes errors as 'application/problem+json'
+// responses, as described in RFC 9457. It can be used as any of the Mux error
+// fields, such as 'm.Error = routex.Problem{}'.
+//
+// The response contains the 'type', 'title', 'status', 'detail' and 'instance'
+// members, along with the 'request_id' (when the Mux has an IDPolicy), 'code' and
+// 'errors' extension members. The 'errors' member lists the fields that failed
+// validation, such as the errors returned by the 'val' package.
+//
+// Type is the base URI of the 'type' member, which is joined with the Error code.
+// When Type is empty or the Error has no code, "about:blank" is used instead.
+//
+// Clients that do not accept JSON will get the Error message as plain text.
+type Problem struct {
+	Type string
+}
+type problem struct {
+	Type     string  `json:"type"`
+	Title    string  `json:"title"`
+	Detail   string  `json:"detail,omitempty"`
+	Instance string  `json:"instance,omitempty"`
+	ID       string  `json:"request_id,omitempty"`
+	Code     string  `json:"code,omitempty"`
+	Errors   []Field `json:"errors,omitempty"`
+	Status   int     `json:"status"`
+}
+
+// RespondError allows Problem to fulfill the ErrorResponder interface.
+func (p Problem) RespondError(err error, w http.ResponseWriter, r *Request) {
+	e := errorOf(http.StatusInternalServerError, err)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if !accepts(r.Header.Get("Accept"), problemTypes) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(e.Status)
+		w.Write([]byte(e.Message + "\n"))
+		return
+	}
+	v := problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(e.Status),
+		Instance: r.URL.Path,
+		ID:       r.id,
+		Code:     e.Code,
+		Errors:   e.Fields,
+		Status:   e.Status,
+	}
+	if e.Message != v.Title {
+		v.Detail = e.Message
+	}
+	if len(p.Type) > 0 && len(e.Code) > 0 {
+		v.Type = p.Type + e.Code
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -31,6 +31,12 @@ type Request struct {
 	ctx    context.Context
 	Values values
 	*http.Request
+	cors  *CORS
+	next  Handler
+	id    string
+	route string
+	allow []string
+	head  bool
 }
 
 // Validator is an interface that allows for validation of Content data. By design,
@@ -74,6 +80,19 @@ func (r *Request) IsOptions() bool {
 	return r.Method == http.MethodOptions
 }
 
+// Allowed returns the sorted list of HTTP methods that are allowed on the matched
+// path. This is only set when the Request is passed to an ErrorResponder with a
+// 405 status and is also sent in the 'Allow' Header.
+func (r *Request) Allowed() []string {
+	return r.allow
+}
+
+// Route returns the pattern (or expression) of the route that matched this
+// Request. This returns an empty string if no route was matched.
+func (r *Request) Route() string {
+	return r.route
+}
+
 // Marshal will attempt to unmarshal the JSON body in the Request into the supplied
 // interface.
 //
@@ -89,10 +108,18 @@ func (r *Request) Marshal(i any) error {
 
 // Context returns the request's context. The returned context is always non-nil.
 //
-// This is a child of the base Handler context if supplied on Mux creation
-// and can be canceled if the Handler is closed or any timeout is passed.
+// This context is canceled when the client connection is closed, the base Handler
+// context (if supplied on Mux creation) is canceled or any timeout is passed,
+// whichever happens first. Values are taken from both the client request context
+// and the base Handler context.
 func (r *Request) Context() context.Context {
-	return r.ctx
+	if r.ctx != nil {
+		return r.ctx
+	}
+	if r.Request != nil {
+		return r.Request.Context()
+	}
+	return context.Background()
 }
 
 // Content returns a content map based on the JSON body data passed in this request.
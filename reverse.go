@@ -0,0 +1,149 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"net/url"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// ErrNotReversible is an error returned from the 'URL' function when the route
+// expression contains a section that is not a literal or a named capture group,
+// so a concrete path cannot be generated from it.
+const ErrNotReversible = errStr("route expression cannot be reversed")
+
+// URL generates a concrete path for the route with the supplied name. The named
+// capture groups (or pattern parameters) of the route will be substituted with
+// the values in the supplied map.
+//
+// Each value is checked against the sub-expression of its capture group and will
+// return an error if it does not match. Optional sections of the expression are
+// only included when they contain a supplied value.
+//
+// This function returns an error if no route has the supplied name, a required
+// value is missing or the route contains a section that cannot be reversed.
+func (m *Mux) URL(name string, v map[string]string) (string, error) {
+	e, ok := m.load().names[name]
+	if !ok {
+		return "", errStr(`route "` + name + `" does not exist`)
+	}
+	r, err := syntax.Parse(e.matcher.String(), syntax.Perl)
+	if err != nil {
+		return "", &errValue{s: `route "` + name + `" parse`, e: err}
+	}
+	var b strings.Builder
+	if err = reverse(&b, r.Simplify(), v); err != nil {
+		return "", &errValue{s: `route "` + name + `"`, e: err}
+	}
+	return b.String(), nil
+}
+func (r *route) Name(n string) Route {
+	r.update(func(t *table) error {
+		e := t.entry(r.id)
+		if e == nil {
+			return ErrNotExists
+		}
+		if len(e.name) > 0 && t.names[e.name] == e {
+			delete(t.names, e.name)
+		}
+		if e.name = n; len(n) == 0 {
+			return nil
+		}
+		if t.names == nil {
+			t.names = make(map[string]*entry, 1)
+		}
+		if o := t.names[n]; o != nil && o.seq != e.seq {
+			if v := t.entry(o.seq); v != nil {
+				v.name = ""
+			}
+		}
+		t.names[n] = e
+		return nil
+	})
+	return r
+}
+func supplied(r *syntax.Regexp, v map[string]string) bool {
+	if r.Op == syntax.OpCapture && len(r.Name) > 0 {
+		_, ok := v[r.Name]
+		return ok
+	}
+	for i := range r.Sub {
+		if supplied(r.Sub[i], v) {
+			return true
+		}
+	}
+	return false
+}
+func reverse(b *strings.Builder, r *syntax.Regexp, v map[string]string) error {
+	switch r.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+	case syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+	case syntax.OpLiteral:
+		b.WriteString(string(r.Rune))
+	case syntax.OpConcat:
+		for i := range r.Sub {
+			if err := reverse(b, r.Sub[i], v); err != nil {
+				return err
+			}
+		}
+	case syntax.OpCapture:
+		if len(r.Name) == 0 {
+			return reverse(b, r.Sub[0], v)
+		}
+		s, ok := v[r.Name]
+		if !ok {
+			return &errValue{s: `value "` + r.Name + `"`, e: ErrNotExists}
+		}
+		x, err := regexp.Compile(`^(?:` + r.Sub[0].String() + `)$`)
+		if err != nil {
+			return err
+		}
+		if !x.MatchString(s) {
+			return errStr(`value "` + r.Name + `" does not match "` + r.Sub[0].String() + `"`)
+		}
+		b.WriteString((&url.URL{Path: s}).EscapedPath())
+	case syntax.OpQuest, syntax.OpStar:
+		if !supplied(r, v) {
+			return nil
+		}
+		return reverse(b, r.Sub[0], v)
+	case syntax.OpPlus:
+		return reverse(b, r.Sub[0], v)
+	case syntax.OpRepeat:
+		if r.Min == 0 && !supplied(r, v) {
+			return nil
+		}
+		for i := 0; i < r.Min || i == 0; i++ {
+			if err := reverse(b, r.Sub[0], v); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		for i := range r.Sub {
+			var t strings.Builder
+			if err := reverse(&t, r.Sub[i], v); err == nil {
+				b.WriteString(t.String())
+				return nil
+			}
+		}
+		return ErrNotReversible
+	default:
+		return ErrNotReversible
+	}
+	return nil
+}
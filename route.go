@@ -0,0 +1,101 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import "sync/atomic"
+
+// route is the Route returned when adding a Handler to a Mux. Handlers stored
+// in a table are never changed, so a route refers to its Handler by the ID of
+// its entry and any changes are made to a copy of the Handler, which replaces
+// it in a copy of the table.
+type route struct {
+	m  atomic.Pointer[Mux]
+	id uint64
+}
+
+func newRoute(m *Mux) *route {
+	r := new(route)
+	r.m.Store(m)
+	return r
+}
+
+// update runs the supplied function against a copy of the table of the Mux this
+// route belongs to. The Mux may change if it is swapped while waiting for the
+// lock, so it is loaded again once the lock is held.
+func (r *route) update(f func(*table) error) {
+	for {
+		m := r.m.Load()
+		m.lock.Lock()
+		if r.m.Load() == m {
+			m.update(f)
+			m.lock.Unlock()
+			return
+		}
+		m.lock.Unlock()
+	}
+}
+func (r *route) change(f func(*entry, *handler)) Route {
+	r.update(func(t *table) error {
+		e := t.entry(r.id)
+		if e == nil {
+			return ErrNotExists
+		}
+		h := e.own(r)
+		if h == nil {
+			return ErrNotExists
+		}
+		f(e, h)
+		return nil
+	})
+	return r
+}
+
+// own replaces the Handler of the supplied route in this entry with a copy and
+// returns it. This returns nil if the route has no Handler in this entry.
+func (e *entry) own(r *route) *handler {
+	var o *handler
+	if e.base != nil && e.base.r == r {
+		o = e.base
+	}
+	for _, h := range e.method {
+		if h.r == r {
+			o = h
+		}
+	}
+	for _, h := range e.match {
+		if h.r == r {
+			o = h
+		}
+	}
+	if o == nil {
+		return nil
+	}
+	n := new(handler)
+	if *n = *o; e.base == o {
+		e.base = n
+	}
+	for k, h := range e.method {
+		if h == o {
+			e.method[k] = n
+		}
+	}
+	for i := range e.match {
+		if e.match[i] == o {
+			e.match[i] = n
+		}
+	}
+	return n
+}
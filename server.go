@@ -0,0 +1,200 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// drain is the default amount of time the Server waits for active requests to
+// finish when shutting down.
+const drain = time.Second * 30
+
+// Server is a struct that wraps a 'http.Server' around a Mux and manages its
+// lifecycle. Servers can be created using the 'NewServer' function.
+//
+// The 'ListenAndServe*' and 'Serve' functions will block until the Server is
+// shutdown, either by calling 'Shutdown', receiving a SIGINT or SIGTERM signal or
+// the Mux base Context being canceled.
+//
+// When shutting down, the Server will mark itself as not ready, wait for the Delay
+// duration (so load balancers can stop sending requests), cancel the Mux base
+// Context and then wait for the Drain duration (which defaults to 30s when zero)
+// for active requests to finish before closing any remaining connections.
+//
+// When Health is true, the Server will answer '/healthz' (always 200 while running)
+// and '/readyz' (200 when ready, 503 otherwise) requests before they reach the Mux.
+type Server struct {
+	http.Server
+	Mux *Mux
+
+	x      context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+
+	Drain  time.Duration
+	Delay  time.Duration
+	active atomic.Int64
+	ready  atomic.Bool
+	stop   atomic.Bool
+	Health bool
+}
+
+// NewServer returns a new Server that will listen on the supplied address and
+// pass requests to the supplied Mux.
+//
+// The Mux base Context (if any) is replaced with a child Context that is canceled
+// once the Server is shutdown.
+func NewServer(addr string, m *Mux) *Server {
+	x := m.ctx
+	if x == nil {
+		x = context.Background()
+	}
+	s := &Server{Mux: m, done: make(chan struct{})}
+	s.Addr, s.Handler = addr, s
+	m.ctx, s.cancel = context.WithCancel(x)
+	s.x = x
+	return s
+}
+
+// Ready returns true if the Server is running and is not shutting down.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Active returns the amount of requests that are currently being handled by
+// the Mux.
+func (s *Server) Active() int64 {
+	return s.active.Load()
+}
+
+// SetReady changes the readiness of the Server. This can be used to fail the
+// '/readyz' check while the Server is running, such as while warming up.
+func (s *Server) SetReady(v bool) {
+	s.ready.Store(v)
+}
+
+// Serve accepts connections on the supplied Listener and blocks until the Server
+// is shutdown. This returns nil if the Server was shutdown gracefully.
+func (s *Server) Serve(l net.Listener) error {
+	return s.run(func() error { return s.Server.Serve(l) })
+}
+
+// ListenAndServe listens on the Server address and blocks until the Server is
+// shutdown. This returns nil if the Server was shutdown gracefully.
+func (s *Server) ListenAndServe() error {
+	return s.run(s.Server.ListenAndServe)
+}
+
+// Shutdown gracefully shuts down the Server and blocks until all active requests
+// are finished, the Drain duration passes or the supplied Context is canceled,
+// in which case the remaining connections are closed.
+func (s *Server) Shutdown(x context.Context) error {
+	s.stop.Store(true)
+	s.SetReady(false)
+	if s.Delay > 0 {
+		t := time.NewTimer(s.Delay)
+		select {
+		case <-t.C:
+		case <-x.Done():
+			t.Stop()
+		}
+	}
+	s.cancel()
+	d := s.Drain
+	if d <= 0 {
+		d = drain
+	}
+	c, f := context.WithTimeout(x, d)
+	err := s.Server.Shutdown(c)
+	if f(); err != nil {
+		s.Server.Close()
+	}
+	s.once.Do(func() { close(s.done) })
+	return err
+}
+
+// ListenAndServeTLS listens on the Server address using TLS with the supplied
+// certificate and key files and blocks until the Server is shutdown. This returns
+// nil if the Server was shutdown gracefully.
+func (s *Server) ListenAndServeTLS(cert, key string) error {
+	return s.run(func() error { return s.Server.ListenAndServeTLS(cert, key) })
+}
+func (s *Server) run(f func() error) error {
+	w := make(chan os.Signal, 1)
+	signal.Notify(w, syscall.SIGINT, syscall.SIGTERM)
+	e := make(chan error, 1)
+	s.SetReady(true)
+	go func() { e <- f() }()
+	var err error
+	select {
+	case err = <-e:
+	case <-w:
+	case <-s.x.Done():
+	}
+	// Stop catching signals before draining, so a second signal will stop the
+	// process instead of waiting for the drain to finish.
+	if signal.Stop(w); err == nil {
+		err = s.Shutdown(context.Background())
+		<-e
+		return err
+	}
+	if s.SetReady(false); err != http.ErrServerClosed {
+		return err
+	}
+	// The Server returns as soon as Shutdown starts, so wait for the drain to
+	// finish. If it did not start, the Server was closed directly and there is
+	// nothing to wait for.
+	if s.stop.Load() {
+		<-s.done
+	}
+	return nil
+}
+func (s *Server) health(w http.ResponseWriter, p string) bool {
+	var c int
+	switch {
+	case p == "/healthz":
+		c = http.StatusOK
+	case p != "/readyz":
+		return false
+	case s.Ready():
+		c = http.StatusOK
+	default:
+		c = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(c)
+	w.Write([]byte(http.StatusText(c) + "\n"))
+	return true
+}
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Health && (r.Method == http.MethodGet || r.Method == http.MethodHead) && s.health(w, r.URL.Path) {
+		return
+	}
+	s.active.Add(1)
+	defer s.active.Add(-1)
+	s.Mux.ServeHTTP(w, r)
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func serve(t *testing.T, h Handler) (*Server, string, chan error) {
+	m := New()
+	m.Must("^/$", h)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		s = NewServer("", m)
+		e = make(chan error, 1)
+	)
+	go func() { e <- s.Serve(l) }()
+	for !s.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+	return s, "http://" + l.Addr().String() + "/", e
+}
+func TestServerShutdown(t *testing.T) {
+	c := make(chan struct{})
+	s, u, e := serve(t, Func(func(context.Context, http.ResponseWriter, *Request) {
+		close(c)
+		time.Sleep(500 * time.Millisecond)
+	}))
+	go http.Get(u)
+	<-c
+	go s.Shutdown(context.Background())
+	if err := <-e; err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Active(); n != 0 {
+		t.Fatalf("Serve returned with %d active requests", n)
+	}
+}
+func TestServerClose(t *testing.T) {
+	s, _, e := serve(t, Func(nil))
+	s.Server.Close()
+	select {
+	case err := <-e:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Serve did not return after Close")
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"regexp"
+	"sort"
+	"unsafe"
+)
+
+// table is an immutable snapshot of the routes of a Mux. Any changes to the routes
+// are made on a copy of the current table (and copies of any changed entries),
+// which is then atomically stored in the Mux. Requests load the table once and
+// keep using it until they complete, so they never need to take a lock.
+type table struct {
+	index  *index
+	names  map[string]*entry
+	hosts  []*entry
+	routes router
+}
+
+var empty = new(table)
+
+func (t *table) copy() *table {
+	n := &table{
+		hosts:  append([]*entry(nil), t.hosts...),
+		routes: append(router(nil), t.routes...),
+	}
+	if len(t.names) > 0 {
+		n.names = make(map[string]*entry, len(t.names))
+		for k, v := range t.names {
+			n.names[k] = v
+		}
+	}
+	return n
+}
+func (t *table) build() {
+	sort.Sort(t.routes)
+	t.index = t.routes.index()
+}
+func (e *entry) clone() *entry {
+	n := *e
+	if e.method != nil {
+		n.method = make(map[string]*handler, len(e.method))
+		for k, v := range e.method {
+			n.method[k] = v
+		}
+	}
+	n.match = append([]*handler(nil), e.match...)
+	return &n
+}
+func (t *table) find(id uint64) int {
+	for i := range t.routes {
+		if t.routes[i].seq == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// entry returns a writable copy of the entry with the supplied ID that replaces
+// the existing entry in this table. This returns nil if the ID is not found.
+func (t *table) entry(id uint64) *entry {
+	i := t.find(id)
+	if i == -1 {
+		return nil
+	}
+	e := t.routes[i].clone()
+	if t.routes[i] = e; len(e.name) > 0 && t.names[e.name] != nil && t.names[e.name].seq == id {
+		t.names[e.name] = e
+	}
+	return e
+}
+func (m *Mux) load() *table {
+	if t, ok := m.table.Load().(*table); ok {
+		return t
+	}
+	return empty
+}
+
+// update runs the supplied function against a copy of the current table and
+// stores the result if it does not return an error. The Mux lock must be held.
+func (m *Mux) update(f func(*table) error) error {
+	t := m.load().copy()
+	if err := f(t); err != nil {
+		return err
+	}
+	t.build()
+	m.table.Store(t)
+	return nil
+}
+func (t *table) lookup(path string) int {
+	for i := range t.routes {
+		if t.routes[i].host == nil && (t.routes[i].matcher.String() == path || t.routes[i].pattern == path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Swap atomically exchanges the routes of this Mux with the routes of the supplied
+// Mux. This can be used to build a complete route table on a separate Mux and
+// then replace the live routes at once. The previous routes are left in the
+// supplied Mux, so swapping again will restore them.
+//
+// Requests in progress will continue to use the routes they started with. Any
+// Routes returned when adding to either Mux will now modify the Mux they were
+// swapped into.
+//
+// The Mux options, Middleware and parameter types are not swapped.
+func (m *Mux) Swap(n *Mux) {
+	if n == nil || n == m {
+		return
+	}
+	// Lock both in address order so a Swap from each side can't deadlock.
+	x, y := m, n
+	if uintptr(unsafe.Pointer(x)) > uintptr(unsafe.Pointer(y)) {
+		x, y = y, x
+	}
+	x.lock.Lock()
+	y.lock.Lock()
+	a, b := m.load(), n.load()
+	a.bind(n)
+	b.bind(m)
+	m.table.Store(b)
+	n.table.Store(a)
+	// The entry IDs move with the tables, so the counters must too.
+	m.seq, n.seq = n.seq, m.seq
+	y.lock.Unlock()
+	x.lock.Unlock()
+}
+
+// bind moves the Routes in the table to the supplied Mux. The Mux is stored
+// atomically, as Route functions load it before taking the lock.
+func (t *table) bind(m *Mux) {
+	for _, l := range [...][]*entry{t.routes, t.hosts} {
+		for _, e := range l {
+			if e.base != nil && e.base.r != nil {
+				e.base.r.m.Store(m)
+			}
+			for _, h := range e.method {
+				h.r.m.Store(m)
+			}
+			for _, h := range e.match {
+				h.r.m.Store(m)
+			}
+		}
+	}
+}
+
+// Remove removes the route with the supplied regex expression path (or pattern)
+// and all of its Handlers. Routes that have a Host expression are not removed.
+//
+// Requests in progress will continue to use the routes they started with. This
+// function returns false if no route with the supplied path exists.
+func (m *Mux) Remove(path string) bool {
+	m.lock.Lock()
+	err := m.update(func(t *table) error {
+		i := t.lookup(path)
+		if i == -1 {
+			return ErrNotExists
+		}
+		if e := t.routes[i]; len(e.name) > 0 && t.names[e.name] == e {
+			delete(t.names, e.name)
+		}
+		t.routes = append(t.routes[:i], t.routes[i+1:]...)
+		return nil
+	})
+	m.lock.Unlock()
+	return err == nil
+}
+
+// MustReplace adds the Handler to the supplied regex expression path, replacing
+// any existing Handler for the path (or the supplied methods) instead of returning
+// an error. Routes with match conditions on the path are kept.
+//
+// This function panics if the regex expression is invalid.
+func (m *Mux) MustReplace(path string, h Handler, methods ...string) Route {
+	v, err := m.Replace(path, h, methods...)
+	if err != nil {
+		panic(err.Error())
+	}
+	return v
+}
+
+// Replace adds the Handler to the supplied regex expression path, replacing any
+// existing Handler for the path (or the supplied methods) instead of returning
+// an error. Routes with match conditions on the path are kept.
+//
+// The path may also be the pattern of a route added using the 'AddPattern'
+// functions. If the path does not exist, this acts the same as the 'Add' function.
+//
+// Requests in progress will continue to use the Handler they started with. This
+// function returns an error if the regex expression is invalid.
+func (m *Mux) Replace(path string, h Handler, methods ...string) (Route, error) {
+	if len(path) == 0 {
+		return nil, ErrInvalidPath
+	}
+	if h == nil {
+		return nil, ErrInvalidHandler
+	}
+	if t := m.load(); t.lookup(path) >= 0 {
+		return m.add(&entry{matcher: t.routes[t.lookup(path)].matcher}, methods, h, true)
+	}
+	x, err := regexp.Compile(path)
+	if err != nil {
+		return nil, &errValue{s: `path "` + path + `" compile`, e: err}
+	}
+	return m.add(&entry{matcher: x}, methods, h, true)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import "testing"
+
+func TestSwapAdd(t *testing.T) {
+	m, n := New(), New()
+	m.Must("^/x$", Func(nil))
+	n.Must("^/y$", Func(nil))
+	n.Must("^/yyyyyyyyy$", Func(nil))
+	m.Swap(n)
+	m.Must("^/z$", Func(nil)).Name("z").Priority(5)
+	n.Must("^/w$", Func(nil)).Name("w")
+	for _, v := range []*Mux{m, n} {
+		for _, e := range v.load().routes {
+			switch p := e.matcher.String(); {
+			case p == "^/z$":
+				if e.name != "z" || e.prio != 5 {
+					t.Fatalf("route %q: got name %q and priority %d", p, e.name, e.prio)
+				}
+			case p == "^/w$":
+				if e.name != "w" {
+					t.Fatalf("route %q: got name %q", p, e.name)
+				}
+			case len(e.name) > 0 || e.prio != 0:
+				t.Fatalf("route %q: got name %q and priority %d", p, e.name, e.prio)
+			}
+		}
+	}
+	if l := m.Order(); len(l) != 3 || l[0] != "^/z$" {
+		t.Fatalf("unexpected order %v", l)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SlowHandler is an interface that can be set as the 'Slow' field of a Mux to be
+// notified when a Handler takes longer than its timeout to return.
+//
+// The 'HandleSlow' method will be called with the Handler Context, the Request,
+// the timeout of the Handler and the time it took to return. When 'Enforce' is
+// enabled, this is called after the timeout response was already sent.
+type SlowHandler interface {
+	HandleSlow(context.Context, *Request, time.Duration, time.Duration)
+}
+
+// buffer is the ResponseWriter used when the Mux enforces timeouts. The response
+// is kept in memory until the Handler returns, so it can be discarded if the
+// timeout passes first.
+type buffer struct {
+	lock sync.Mutex
+	h    http.Header
+	t    time.Time
+	b    bytes.Buffer
+	c    int
+	done bool
+}
+
+// errTimeout is the cause of a Handler Context that is canceled because its
+// timeout passed, which separates it from a client or Mux base Context cancel.
+var errTimeout = errStr("handler timeout passed")
+
+// panicked is used to pass a panic (and its stack trace) from an enforced Handler
+// goroutine to the goroutine serving the request.
+type panicked struct {
+	v any
+	s []byte
+}
+
+func (r *route) Timeout(d time.Duration) Route {
+	return r.change(func(_ *entry, h *handler) {
+		h.timeout = d
+	})
+}
+func (b *buffer) Header() http.Header {
+	return b.h
+}
+func (b *buffer) Status() int {
+	b.lock.Lock()
+	c := b.c
+	b.lock.Unlock()
+	return c
+}
+func (b *buffer) Written() bool {
+	return b.Status() > 0
+}
+func (b *buffer) BytesWritten() int64 {
+	b.lock.Lock()
+	n := int64(b.b.Len())
+	b.lock.Unlock()
+	return n
+}
+func (b *buffer) Duration() time.Duration {
+	return time.Since(b.t)
+}
+func (b *buffer) WriteHeader(c int) {
+	b.lock.Lock()
+	if b.c == 0 && !b.done && (c < 100 || c > 199) {
+		b.c = c
+	}
+	b.lock.Unlock()
+}
+func (b *buffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.done {
+		return 0, http.ErrHandlerTimeout
+	}
+	if b.c == 0 {
+		b.c = http.StatusOK
+	}
+	return b.b.Write(p)
+}
+
+// flush writes the buffered response to the supplied ResponseWriter.
+func (b *buffer) flush(w http.ResponseWriter) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.done = true
+	for k, v := range b.h {
+		w.Header()[k] = v
+	}
+	if b.c == 0 {
+		b.c = http.StatusOK
+	}
+	w.WriteHeader(b.c)
+	w.Write(b.b.Bytes())
+}
+
+// expire marks the response as timed out, any further writes will return an
+// error. This returns false if the response was already marked.
+func (b *buffer) expire() bool {
+	b.lock.Lock()
+	ok := !b.done
+	b.done = true
+	b.lock.Unlock()
+	return ok
+}
+func (m *Mux) timeoutStatus() int {
+	if m.TimeoutStatus > 0 {
+		return m.TimeoutStatus
+	}
+	return http.StatusServiceUnavailable
+}
+func (m *Mux) slow(x context.Context, r *Request, d, e time.Duration) {
+	if e <= d {
+		return
+	}
+	if m.log != nil {
+		m.log.WarnContext(
+			x, "handler exceeded its timeout", slog.String("url", r.URL.String()), slog.String("route", r.route),
+			slog.Duration("timeout", d), slog.Duration("duration", e),
+		)
+	}
+	if m.Slow != nil {
+		m.Slow.HandleSlow(x, r, d, e)
+	}
+}
+
+// enforce runs the Middleware and Handler in a new goroutine with a buffered
+// ResponseWriter and writes the timeout status using the ErrorResponders if the
+// timeout passes before the Handler returns. If the Context is canceled for any
+// other reason, the Handler is waited on the same as without Enforce.
+//
+// The buffer is expired by whichever side finishes first, so a panic after the
+// timeout response was sent is reported by the Handler goroutine instead.
+func (m *Mux) enforce(x context.Context, h Handler, v *wares, d time.Duration, w http.ResponseWriter, r *Request) {
+	var (
+		b = &buffer{h: make(http.Header), t: time.Now()}
+		o = make(chan struct{})
+		p = make(chan panicked, 1)
+	)
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				if s := stack(); b.expire() {
+					p <- panicked{v: err, s: s}
+				} else {
+					m.late(x, r, err, s)
+				}
+				return
+			}
+			m.slow(x, r, d, time.Since(b.t))
+			close(o)
+		}()
+		m.chain(x, h, v, b, r)
+	}()
+	for c := x.Done(); ; {
+		select {
+		case e := <-p:
+			if m.Recover == RecoverNone {
+				panic(e.v)
+			}
+			panic(e)
+		case <-o:
+			b.flush(w)
+			return
+		case <-c:
+		}
+		if context.Cause(x) != errTimeout || !b.expire() {
+			// Only a passed timeout is answered here. A Handler that panicked first
+			// is received on the next loop, otherwise wait for the Handler to return.
+			c = nil
+			continue
+		}
+		n := m.timeoutStatus()
+		if m.log != nil {
+			m.log.WarnContext(
+				x, "handler timeout passed", slog.String("url", r.URL.String()), slog.String("route", r.route),
+				slog.Duration("timeout", d), slog.Int("status", n),
+			)
+		}
+		m.handleError(NewError(n, "", x.Err()), w, r)
+		return
+	}
+}
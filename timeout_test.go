@@ -0,0 +1,70 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type panics chan any
+
+func (p panics) HandlePanic(_ context.Context, _ *Request, v any, _ []byte) {
+	p <- v
+}
+func TestEnforceCancel(t *testing.T) {
+	x, f := context.WithCancel(context.Background())
+	m := NewContext(x)
+	m.Enforce, m.Timeout = true, time.Second
+	m.Must("^/$", Func(func(x context.Context, w http.ResponseWriter, _ *Request) {
+		f()
+		<-x.Done()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("canceled base Context returned status %d", w.Code)
+	}
+}
+func TestEnforceLatePanic(t *testing.T) {
+	var (
+		m = New()
+		p = make(panics, 1)
+	)
+	m.Enforce, m.Timeout, m.Panic = true, time.Millisecond*50, p
+	m.Must("^/$", Func(func(x context.Context, _ http.ResponseWriter, _ *Request) {
+		<-x.Done()
+		time.Sleep(time.Millisecond * 50)
+		panic("late")
+	}))
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("timeout returned status %d", w.Code)
+	}
+	select {
+	case v := <-p:
+		if v != "late" {
+			t.Fatalf("unexpected panic value %v", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("late panic was not reported")
+	}
+}
@@ -43,10 +43,45 @@ type Validator struct {
 	Optional bool   `json:"optional,omitempty"`
 }
 
+// FieldError is an error returned when a field fails validation. The Name is the
+// name of the field and Err is the reason it failed, which may be another FieldError
+// when the field is validated with a SubSet.
+type FieldError struct {
+	Err  error
+	Name string
+}
+
 // ErrInvalidName is a validation error returned when a Validator rule has an empty
 // name.
 var ErrInvalidName = errors.New("invalid name in set")
 
+func fail(n, s string) error {
+	return &FieldError{Name: n, Err: errors.New(s)}
+}
+func (e *FieldError) Error() string {
+	return "'" + e.Name + "': " + e.Err.Error()
+}
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Field returns the full name of the field that failed validation. Names of
+// fields validated with a SubSet are joined with a '.'.
+func (e *FieldError) Field() string {
+	if v, ok := e.Err.(*FieldError); ok {
+		return e.Name + "." + v.Field()
+	}
+	return e.Name
+}
+
+// Reason returns the reason the field failed validation, without the field name.
+func (e *FieldError) Reason() string {
+	if v, ok := e.Err.(*FieldError); ok {
+		return v.Reason()
+	}
+	return e.Err.Error()
+}
+
 // Validate fulfills the Rule interface.
 func (s SubSet) Validate(i any) error {
 	m, ok := i.(map[string]any)
@@ -60,7 +95,7 @@ func (s SubSet) Validate(i any) error {
 // if the supplied interface does not match the Validator's constraints.
 func (v Validator) Validate(i any) error {
 	if i == nil && v.Type > None {
-		return errors.New("'" + v.Name + "': expected '" + v.Type.String() + "' but got 'null'")
+		return fail(v.Name, "expected '"+v.Type.String()+"' but got 'null'")
 	}
 	if v.Type > None {
 		switch t := i.(type) {
@@ -68,54 +103,54 @@ func (v Validator) Validate(i any) error {
 			if v.Type == Bool {
 				break
 			}
-			return errors.New("'" + v.Name + "': expected '" + v.Type.String() + "' but got 'boolean'")
+			return fail(v.Name, "expected '"+v.Type.String()+"' but got 'boolean'")
 		case string:
 			if v.Type == String {
 				break
 			}
-			return errors.New("'" + v.Name + "': expected '" + v.Type.String() + "' but got 'string'")
+			return fail(v.Name, "expected '"+v.Type.String()+"' but got 'string'")
 		case float64:
 			if v.Type == Number {
 				break
 			}
 			if v.Type == Int {
 				if n, r := modf(t); t != n || r {
-					return errors.New("'" + v.Name + "': expected 'integer' but got 'float'")
+					return fail(v.Name, "expected 'integer' but got 'float'")
 				}
 				break
 			}
-			return errors.New("'" + v.Name + "': expected '" + v.Type.String() + "' but got 'number'")
+			return fail(v.Name, "expected '"+v.Type.String()+"' but got 'number'")
 		default:
 			k := reflect.ValueOf(i).Kind()
 			if k == reflect.Map && v.Type != Object {
-				return errors.New("'" + v.Name + "': expected 'object' but got '" + reflect.TypeOf(i).String() + "'")
+				return fail(v.Name, "expected 'object' but got '"+reflect.TypeOf(i).String()+"'")
 			}
 			if k == reflect.Slice && v.Type < List {
-				return errors.New("'" + v.Name + "': expected '[]object' but got '" + reflect.TypeOf(i).String() + "'")
+				return fail(v.Name, "expected '[]object' but got '"+reflect.TypeOf(i).String()+"'")
 			}
 			if v.Type > List {
 				w, ok := i.([]any)
 				if !ok {
-					return errors.New("'" + v.Name + "': '[]object' value could not be parsed")
+					return fail(v.Name, "'[]object' value could not be parsed")
 				}
 				for e := range w {
 					if v.Type == ListNumber {
 						if _, ok := w[e].(float64); ok {
 							continue
 						}
-						return errors.New("'" + v.Name + "': '[]number' contains invalid entry")
+						return fail(v.Name, "'[]number' contains invalid entry")
 					}
 					if _, ok := w[e].(string); ok {
 						continue
 					}
-					return errors.New("'" + v.Name + "': '[]string' contains invalid entry")
+					return fail(v.Name, "'[]string' contains invalid entry")
 				}
 			}
 		}
 	}
 	for x := range v.Rules {
 		if err := v.Rules[x].Validate(i); err != nil {
-			return errors.New("'" + v.Name + "': " + err.Error())
+			return &FieldError{Name: v.Name, Err: err}
 		}
 	}
 	return nil
@@ -152,7 +187,7 @@ func validate(s []Validator, m routex.Content) error {
 			if s[x].Type == None || s[x].Optional {
 				continue
 			}
-			return errors.New("'" + s[x].Name + "': required")
+			return fail(s[x].Name, "required")
 		}
 		if err := s[x].Validate(i); err != nil {
 			return err
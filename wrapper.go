@@ -76,7 +76,7 @@ func (h wrapper) Handle(x context.Context, w http.ResponseWriter, r *Request) {
 	}
 	c, err := r.ValidateContent(h.v)
 	if err != nil {
-		r.Mux.handleError(http.StatusBadRequest, err.Error(), w, r)
+		r.Mux.handleError(invalid(err), w, r)
 		return
 	}
 	h.h.Handle(x, w, r, c)
@@ -88,7 +88,7 @@ func (m marshaler[T]) Handle(x context.Context, w http.ResponseWriter, r *Reques
 		return
 	}
 	if err := r.ValidateMarshal(m.v, &v); err != nil {
-		r.Mux.handleError(http.StatusBadRequest, err.Error(), w, r)
+		r.Mux.handleError(invalid(err), w, r)
 		return
 	}
 	m.h.Handle(x, w, r, v)
@@ -0,0 +1,154 @@
+// Copyright 2021 - 2023 PurpleSec Team
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package routex
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseWriter is an interface that extends the http.ResponseWriter with
+// information about the response. The ResponseWriter passed to Middleware,
+// Handlers and ErrorResponders by the Mux implements this interface and can be
+// retrieved with a type assertion. It also implements the http.Flusher,
+// http.Hijacker, http.Pusher and io.ReaderFrom interfaces, which return an error
+// (or do nothing) when the underlying ResponseWriter does not support them.
+//
+// Status returns the status written, or zero if the Header was not written yet.
+// Written returns true if the Header was written. BytesWritten returns the amount
+// of body bytes written and Duration returns the time since the request was
+// received by the Mux.
+type ResponseWriter interface {
+	http.ResponseWriter
+	Status() int
+	Written() bool
+	BytesWritten() int64
+	Duration() time.Duration
+}
+
+// writer is the ResponseWriter used by the Mux. When handling a HEAD request
+// with a GET Handler, the body is discarded, but counted so the 'Content-Length'
+// Header can be set if the Handler did not set it. Writing the status is delayed
+// until the Handler returns for the same reason.
+type writer struct {
+	http.ResponseWriter
+	t    time.Time
+	n    int64
+	c    int
+	head bool
+}
+
+func (w *writer) finish() {
+	if w.c == 0 {
+		w.c = http.StatusOK
+	}
+	if w.n > 0 && len(w.Header().Get("Content-Length")) == 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(w.n, 10))
+	}
+	w.ResponseWriter.WriteHeader(w.c)
+}
+func (w *writer) Status() int {
+	return w.c
+}
+func (w *writer) Written() bool {
+	return w.c > 0
+}
+func (w *writer) BytesWritten() int64 {
+	return w.n
+}
+func (w *writer) Duration() time.Duration {
+	return time.Since(w.t)
+}
+func (w *writer) WriteHeader(c int) {
+	if w.c > 0 {
+		return
+	}
+	// Informational statuses (except 101) may be written multiple times before the
+	// actual status.
+	if c >= 100 && c < 200 && c != http.StatusSwitchingProtocols {
+		if !w.head {
+			w.ResponseWriter.WriteHeader(c)
+		}
+		return
+	}
+	if w.c = c; !w.head {
+		w.ResponseWriter.WriteHeader(c)
+	}
+}
+func (w *writer) Write(b []byte) (int, error) {
+	if w.c == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.head {
+		w.n += int64(len(b))
+		return len(b), nil
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+func (w *writer) Flush() {
+	if w.head {
+		return
+	}
+	if w.c == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+func (w *writer) ReadFrom(r io.Reader) (int64, error) {
+	if w.c == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.head {
+		n, err := io.Copy(io.Discard, r)
+		w.n += n
+		return n, err
+	}
+	if f, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err := f.ReadFrom(r)
+		w.n += n
+		return n, err
+	}
+	// Hide our ReadFrom function to prevent io.Copy from calling it.
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+func (w *writer) Push(s string, o *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(s, o)
+	}
+	return http.ErrNotSupported
+}
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	c, b, err := h.Hijack()
+	if err == nil && w.c == 0 {
+		w.c = http.StatusSwitchingProtocols
+	}
+	return c, b, err
+}